@@ -6,7 +6,6 @@ import (
 	"github.com/mbict/webapp/binder/decoder"
 	"net/http"
 	"reflect"
-	"strings"
 	"sync"
 )
 
@@ -81,28 +80,14 @@ func New(options ...BinderOption) webapp.Binder {
 		lock:            sync.RWMutex{},
 	}
 
-	//add default json decoder
+	//add decoder for the encodings registered on the webapp, json and xml by default
 	b.AddDecoder(
 		func(c webapp.Context) bool {
-			return strings.HasPrefix(c.Request().Header.Get(webapp.HeaderContentType), webapp.MIMEApplicationJSON)
+			return c.Encoding(c.Request().Header.Get(webapp.HeaderContentType)) != nil
 		}, func(c webapp.Context, i interface{}) error {
-			if err := webapp.DefaultJSONEncoder.Decode(c, i); err != nil {
-				return errors.New("BindError")
-			}
-			return nil
+			return c.Encoding(c.Request().Header.Get(webapp.HeaderContentType)).Decode(c.Request().Body, i)
 		})
 
-	////add default xml decoder
-	//b.AddDecoder(
-	//	func(c webapp.Context) bool {
-	//		return strings.HasPrefix(c.Request().Header.Get(webapp.HeaderContentType), webapp.MIMETextXML)
-	//	}, func(c webapp.Context, i interface{}) error {
-	//		if err := webapp.DefaultXMLEncoder.Decode(c, i); err != nil {
-	//			return errors.New("BindError")
-	//		}
-	//		return nil
-	//	})
-	//
 	////add form multipart post decoder
 	//b.AddDecoder(
 	//	func(c webapp.Context) bool {
@@ -5,5 +5,5 @@ import (
 )
 
 func queryGetterFunc(c webapp.Context) getter {
-	return mapGetter(c.Request().URL.Query())
+	return mapGetter(c.QueryParams())
 }
@@ -3,11 +3,16 @@ package webapp
 import (
 	"bytes"
 	stdContext "context"
+	"encoding/xml"
+	"errors"
 	"github.com/mbict/webapp/container"
 	"io"
+	"mime"
 	"mime/multipart"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"sync"
 )
 
@@ -38,15 +43,16 @@ type Context interface {
 	// Method returns the http method used for the request
 	Method() string
 
-	// RealIP returns the client's network address based on `X-Forwarded-For`
-	// or `X-Real-IP` request header.
-	// The behavior can be configured using `Echo#IPExtractor`.
+	// RealIP returns the client's network address, by default the remote address of the connection.
+	// Use WithIPExtractor to take the address from the `X-Forwarded-For`, `X-Real-Ip` or `Forwarded` header set by
+	// trusted proxies.
 	RealIP() string
 
 	// Path returns the registered path for the handler.
 	Path() string
 
-	// Param returns path parameter by name.
+	// Param returns path parameter by name, when the path has no parameter with the name the parameters of the
+	// host pattern are used.
 	Param(name string) string
 
 	// ParamNames returns path parameter names.
@@ -64,7 +70,7 @@ type Context interface {
 	// QueryParam returns the query param for the provided name.
 	QueryParam(name string) string
 
-	// QueryParams returns the query parameters as `url.Values`.
+	// QueryParams returns the query parameters as `url.Values`, the query is parsed once per request.
 	QueryParams() url.Values
 
 	// QueryString returns the URL query string.
@@ -73,7 +79,8 @@ type Context interface {
 	// FormValue returns the form field value for the provided name.
 	FormValue(name string) string
 
-	// FormParams returns the form parameters as `url.Values`.
+	// FormParams returns the form parameters as `url.Values`, the query parameters are included.
+	// A multipart form is parsed with the multipart memory limit of the webapp (see WithMultipartMemory).
 	FormParams() (url.Values, error)
 
 	// FormFile returns the multipart form file for the provided name.
@@ -82,7 +89,8 @@ type Context interface {
 	// MultipartForm returns the multipart form.
 	MultipartForm() (*multipart.Form, error)
 
-	// Cookie returns the named cookie provided in the request.
+	// Cookie returns the named cookie provided in the request, ErrCookieNotFound is returned when the request has no
+	// cookie with the name.
 	Cookie(name string) (*http.Cookie, error)
 
 	// SetCookie adds a `Set-Cookie` header in HTTP response.
@@ -108,6 +116,10 @@ type Context interface {
 	// BindQueryParams binds the all request params except the body into provided type `i`.
 	BindQueryParams(i interface{}) error
 
+	// Encoding returns the registered encoding for the content type, the json and xml encodings or an encoding added
+	// with WithEncoding. Nil is returned when no encoding is registered for the media type.
+	Encoding(contentType string) Encoding
+
 	// Validate validates provided `i`. It is usually called after `Context#Bind()`.
 	// Validator must be registered using `Echo#Validator`.
 	Validate(i interface{}) error
@@ -138,7 +150,8 @@ type Context interface {
 	JSONBlob(code int, b []byte) error
 
 	// JSONP sends a JSONP response with status code. It uses `callback` to construct
-	// the JSONP payload.
+	// the JSONP payload. ErrInvalidJSONPCallback is returned when the callback is not a
+	// valid javascript identifier or a dot separated path of identifiers.
 	JSONP(code int, callback string, i interface{}) error
 
 	// JSONPBlob sends a JSONP blob response with status code. It uses `callback`
@@ -154,13 +167,21 @@ type Context interface {
 	// XMLBlob sends an XML blob response with status code.
 	XMLBlob(code int, b []byte) error
 
+	// Negotiate sends the data in the media type of the registered encodings that is accepted best by the `Accept`
+	// header of the request, html is rendered when a template is set with NegotiateTemplate. The `Vary: Accept`
+	// header is set and ErrNotAcceptable is returned when none of the offered media types is accepted or the
+	// encodings cannot encode the data. ErrEncoderNotRegistered is returned when a media type offered with
+	// NegotiateOffers has no registered encoding.
+	Negotiate(code int, data interface{}, options ...NegotiateOption) error
+
 	// Blob sends a blob response with status code and content type.
 	Blob(code int, contentType string, b []byte) error
 
 	// Stream sends a streaming response with status code and content type.
 	Stream(code int, contentType string, r io.Reader) error
 
-	// File sends a response with the content of the file.
+	// File sends a response with the content of the file, range and conditional requests are supported.
+	// A directory sends its index.html, ErrNotFound is returned when the file does not exist.
 	File(file string) error
 
 	// Attachment sends a response as attachment, prompting client to save the
@@ -195,17 +216,23 @@ type ParamsContext interface {
 	SetParamValues(values ...string)
 	SetParamValue(name, values string)
 	ParamValuesPtr() *[]string
+	SetCurrentRoute(route RouteInfo)
+	SetHostParamNames(names ...string)
+	HostParamValuesPtr() *[]string
 }
 
 type context struct {
 	request  *http.Request
 	response *response
+	query    url.Values
 
-	currentRoute RouteInfo
-	paramNames   []string
-	paramValues  []string
-	storeLock    sync.RWMutex
-	store        map[string]interface{}
+	currentRoute    RouteInfo
+	paramNames      []string
+	paramValues     []string
+	hostParamNames  []string
+	hostParamValues []string
+	storeLock       sync.RWMutex
+	store           map[string]interface{}
 
 	webapp *webapp
 }
@@ -220,6 +247,7 @@ func (c *context) Context() stdContext.Context {
 
 func (c *context) SetRequest(r *http.Request) {
 	c.request = r
+	c.query = nil
 }
 
 func (c *context) Response() Response {
@@ -237,17 +265,24 @@ func (c *context) Routes() Routes {
 	return c.webapp.Routes()
 }
 
+func (c *context) Encoding(contentType string) Encoding {
+	return bindEncoding(c.webapp.encodings.get(contentType), c)
+}
+
 func (c *context) CurrentRoute() RouteInfo {
 	return c.currentRoute
 }
 
+func (c *context) SetCurrentRoute(route RouteInfo) {
+	c.currentRoute = route
+}
+
 func (c *context) Method() string {
 	return c.request.Method
 }
 
 func (c *context) RealIP() string {
-	//TODO implement me
-	panic("implement me")
+	return c.webapp.ipExtractor(c.request)
 }
 
 func (c *context) Path() string {
@@ -262,6 +297,11 @@ func (c *context) Param(name string) string {
 			}
 		}
 	}
+	for i, n := range c.hostParamNames {
+		if i < len(c.hostParamValues) && n == name {
+			return c.hostParamValues[i]
+		}
+	}
 	return ""
 }
 
@@ -312,54 +352,82 @@ func (c *context) ParamValuesPtr() *[]string {
 	return &c.paramValues
 }
 
+func (c *context) SetHostParamNames(names ...string) {
+	c.hostParamNames = names
+}
+
+func (c *context) HostParamValuesPtr() *[]string {
+	return &c.hostParamValues
+}
+
 func (c *context) QueryParam(name string) string {
-	//TODO implement me
-	panic("implement me")
+	return c.QueryParams().Get(name)
 }
 
 func (c *context) QueryParams() url.Values {
-	//TODO implement me
-	panic("implement me")
+	// the parsed query is cached for the request
+	if c.query == nil {
+		c.query = c.request.URL.Query()
+	}
+	return c.query
 }
 
 func (c *context) QueryString() string {
-	//TODO implement me
-	panic("implement me")
+	return c.request.URL.RawQuery
 }
 
 func (c *context) FormValue(name string) string {
-	//TODO implement me
-	panic("implement me")
+	form, err := c.FormParams()
+	if err != nil {
+		return ""
+	}
+	return form.Get(name)
 }
 
 func (c *context) FormParams() (url.Values, error) {
-	//TODO implement me
-	panic("implement me")
+	// the parsed form is kept by the request, parsing again is a no-op
+	if mediaType, _, _ := mime.ParseMediaType(c.request.Header.Get(HeaderContentType)); mediaType == MIMEMultipartForm {
+		if err := c.request.ParseMultipartForm(c.webapp.multipartMemory); err != nil {
+			return nil, err
+		}
+	} else if err := c.request.ParseForm(); err != nil {
+		return nil, err
+	}
+	return c.request.Form, nil
 }
 
 func (c *context) FormFile(name string) (*multipart.FileHeader, error) {
-	//TODO implement me
-	panic("implement me")
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, err
+	}
+	if files := form.File[name]; len(files) > 0 {
+		return files[0], nil
+	}
+	return nil, http.ErrMissingFile
 }
 
 func (c *context) MultipartForm() (*multipart.Form, error) {
-	//TODO implement me
-	panic("implement me")
+	if err := c.request.ParseMultipartForm(c.webapp.multipartMemory); err != nil {
+		return nil, err
+	}
+	return c.request.MultipartForm, nil
 }
 
 func (c *context) Cookie(name string) (*http.Cookie, error) {
-	//TODO implement me
-	panic("implement me")
+	cookie, err := c.request.Cookie(name)
+	if errors.Is(err, http.ErrNoCookie) {
+		return nil, ErrCookieNotFound
+	}
+	return cookie, err
 }
 
 func (c *context) SetCookie(cookie *http.Cookie) {
-	//TODO implement me
-	panic("implement me")
+	http.SetCookie(c.response, cookie)
 }
 
 func (c *context) Cookies() []*http.Cookie {
-	//TODO implement me
-	panic("implement me")
+	return c.request.Cookies()
 }
 
 func (c *context) Get(key string) interface{} {
@@ -429,38 +497,70 @@ func (c *context) JSON(code int, i interface{}) error {
 }
 
 func (c *context) JSONPretty(code int, i interface{}, indent string) error {
-	//TODO implement me
-	panic("implement me")
+	c.Response().Header().Set(HeaderContentType, MIMEApplicationJSONCharsetUTF8)
+	c.response.SetStatusCode(code)
+	return c.webapp.JsonEncoder().Encode(c, i, indent)
 }
 
 func (c *context) JSONBlob(code int, b []byte) error {
-	//TODO implement me
-	panic("implement me")
+	return c.Blob(code, MIMEApplicationJSONCharsetUTF8, b)
 }
 
 func (c *context) JSONP(code int, callback string, i interface{}) error {
-	//TODO implement me
-	panic("implement me")
+	if !validJSONPCallback(callback) {
+		return ErrInvalidJSONPCallback
+	}
+
+	c.Response().Header().Set(HeaderContentType, MIMEApplicationJavaScriptCharsetUTF8)
+	c.response.SetStatusCode(code)
+	if _, err := c.response.Write([]byte(jsonpPrefix + callback + "(")); err != nil {
+		return err
+	}
+	if err := c.webapp.JsonEncoder().Encode(c, i, ""); err != nil {
+		return err
+	}
+	_, err := c.response.Write([]byte(");"))
+	return err
 }
 
 func (c *context) JSONPBlob(code int, callback string, b []byte) error {
-	//TODO implement me
-	panic("implement me")
+	if !validJSONPCallback(callback) {
+		return ErrInvalidJSONPCallback
+	}
+
+	c.Response().Header().Set(HeaderContentType, MIMEApplicationJavaScriptCharsetUTF8)
+	c.response.WriteHeader(code)
+	if _, err := c.response.Write([]byte(jsonpPrefix + callback + "(")); err != nil {
+		return err
+	}
+	if _, err := c.response.Write(b); err != nil {
+		return err
+	}
+	_, err := c.response.Write([]byte(");"))
+	return err
 }
 
 func (c *context) XML(code int, i interface{}) error {
-	//TODO implement me
-	panic("implement me")
+	return c.XMLPretty(code, i, "")
 }
 
 func (c *context) XMLPretty(code int, i interface{}, indent string) error {
-	//TODO implement me
-	panic("implement me")
+	c.Response().Header().Set(HeaderContentType, MIMEApplicationXMLCharsetUTF8)
+	c.response.SetStatusCode(code)
+	if _, err := c.response.Write([]byte(xml.Header)); err != nil {
+		return err
+	}
+	return c.webapp.XmlEncoder().Encode(c, i, indent)
 }
 
 func (c *context) XMLBlob(code int, b []byte) error {
-	//TODO implement me
-	panic("implement me")
+	c.Response().Header().Set(HeaderContentType, MIMEApplicationXMLCharsetUTF8)
+	c.response.WriteHeader(code)
+	if _, err := c.response.Write([]byte(xml.Header)); err != nil {
+		return err
+	}
+	_, err := c.response.Write(b)
+	return err
 }
 
 func (c *context) Blob(code int, contentType string, b []byte) error {
@@ -478,18 +578,29 @@ func (c *context) Stream(code int, contentType string, r io.Reader) error {
 }
 
 func (c *context) File(file string) error {
-	//TODO implement me
-	panic("implement me")
+	dir, name := filepath.Split(filepath.Clean(file))
+	if dir == "" {
+		dir = "."
+	}
+	s := staticFiles{fsys: os.DirFS(dir)}
+	return s.file(c, name)
 }
 
 func (c *context) Attachment(file string, name string) error {
-	//TODO implement me
-	panic("implement me")
+	return c.contentDisposition(file, name, "attachment")
 }
 
 func (c *context) Inline(file string, name string) error {
-	//TODO implement me
-	panic("implement me")
+	return c.contentDisposition(file, name, "inline")
+}
+
+func (c *context) contentDisposition(file, name, dispositionType string) error {
+	disposition := mime.FormatMediaType(dispositionType, map[string]string{"filename": name})
+	if disposition == "" {
+		disposition = dispositionType
+	}
+	c.response.Header().Set(HeaderContentDisposition, disposition)
+	return c.File(file)
 }
 
 func (c *context) NoContent() error {
@@ -531,8 +642,18 @@ func (c *context) Container() container.Container {
 func (c *context) reset(request *http.Request, response http.ResponseWriter) {
 	c.request = request
 	c.response.reset(response)
+	c.query = nil
 	c.store = nil
+	c.currentRoute = nil
 	c.paramNames = nil
+	c.hostParamNames = nil
+	c.hostParamValues = c.hostParamValues[0:0]
+
+	// routes registered after the context was created can have more params than allocated
+	if cap(c.paramValues) < c.webapp.maxParams {
+		c.paramValues = make([]string, c.webapp.maxParams)
+		return
+	}
 	c.paramValues = c.paramValues[0:c.webapp.maxParams]
 	for i := 0; i < c.webapp.maxParams; i++ {
 		c.paramValues[i] = ""
@@ -0,0 +1,325 @@
+package webapp
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveContext runs the handler for the request and returns the response recorder
+func serveContext(T *testing.T, app WebApp, req *http.Request, handler HandlerFunc) *httptest.ResponseRecorder {
+	T.Helper()
+	app.Any("/", handler)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+	return rw
+}
+
+func Test_context_query_params(T *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/?name=foo&tag=a&tag=b", nil)
+
+	serveContext(T, New(), req, func(c Context) error {
+		assert.Equal(T, "foo", c.QueryParam("name"))
+		assert.Equal(T, "", c.QueryParam("missing"))
+		assert.Equal(T, url.Values{"name": {"foo"}, "tag": {"a", "b"}}, c.QueryParams())
+		assert.Equal(T, "name=foo&tag=a&tag=b", c.QueryString())
+
+		// the parsed query is cached for the request
+		c.QueryParams().Set("name", "bar")
+		assert.Equal(T, "bar", c.QueryParam("name"))
+
+		// a new request parses the query again
+		c.SetRequest(httptest.NewRequest(http.MethodGet, "/?name=baz", nil))
+		assert.Equal(T, "baz", c.QueryParam("name"))
+		return nil
+	})
+}
+
+func Test_context_query_params_reset(T *testing.T) {
+	app := New()
+	var names []string
+	app.GET("/", func(c Context) error {
+		names = append(names, c.QueryParam("name"))
+		return nil
+	})
+
+	for _, target := range []string{"/?name=foo", "/?name=bar", "/"} {
+		app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+	assert.Equal(T, []string{"foo", "bar", ""}, names)
+}
+
+func Test_context_form_params(T *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/?source=query", strings.NewReader("name=foo&tag=a&tag=b"))
+	req.Header.Set(HeaderContentType, MIMEApplicationForm)
+
+	serveContext(T, New(), req, func(c Context) error {
+		assert.Equal(T, "foo", c.FormValue("name"))
+
+		form, err := c.FormParams()
+		require.NoError(T, err)
+		assert.Equal(T, url.Values{"name": {"foo"}, "tag": {"a", "b"}, "source": {"query"}}, form)
+		return nil
+	})
+}
+
+func newMultipartRequest(T *testing.T) *http.Request {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	require.NoError(T, mw.WriteField("name", "foo"))
+	fw, err := mw.CreateFormFile("upload", "report.csv")
+	require.NoError(T, err)
+	_, err = fw.Write([]byte(strings.Repeat("a,b\n", 64)))
+	require.NoError(T, err)
+	require.NoError(T, mw.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/", body)
+	req.Header.Set(HeaderContentType, mw.FormDataContentType())
+	return req
+}
+
+func Test_context_multipart_form(T *testing.T) {
+	serveContext(T, New(), newMultipartRequest(T), func(c Context) error {
+		assert.Equal(T, "foo", c.FormValue("name"))
+
+		form, err := c.MultipartForm()
+		require.NoError(T, err)
+		assert.Equal(T, []string{"foo"}, form.Value["name"])
+
+		file, err := c.FormFile("upload")
+		require.NoError(T, err)
+		assert.Equal(T, "report.csv", file.Filename)
+		assert.EqualValues(T, 256, file.Size)
+
+		_, err = c.FormFile("missing")
+		assert.ErrorIs(T, err, http.ErrMissingFile)
+		return nil
+	})
+}
+
+func Test_context_multipart_memory(T *testing.T) {
+	serveContext(T, New(WithMultipartMemory(16)), newMultipartRequest(T), func(c Context) error {
+		file, err := c.FormFile("upload")
+		require.NoError(T, err)
+
+		// the file does not fit in memory and is stored in a temporary file
+		f, err := file.Open()
+		require.NoError(T, err)
+		defer f.Close()
+		_, onDisk := f.(interface{ Name() string })
+		assert.True(T, onDisk)
+		return nil
+	})
+}
+
+func Test_context_form_params_multipart_memory(T *testing.T) {
+	for _, contentType := range []string{"Multipart/Form-Data", " multipart/form-data"} {
+		T.Run(contentType, func(T *testing.T) {
+			req := newMultipartRequest(T)
+			_, params, _ := strings.Cut(req.Header.Get(HeaderContentType), ";")
+			req.Header.Set(HeaderContentType, contentType+";"+params)
+
+			serveContext(T, New(WithMultipartMemory(16)), req, func(c Context) error {
+				form, err := c.FormParams()
+				require.NoError(T, err)
+				assert.Equal(T, []string{"foo"}, form["name"])
+
+				// the form is parsed as multipart with the memory limit of the webapp
+				require.NotNil(T, c.Request().MultipartForm)
+				f, err := c.Request().MultipartForm.File["upload"][0].Open()
+				require.NoError(T, err)
+				defer f.Close()
+				_, onDisk := f.(interface{ Name() string })
+				assert.True(T, onDisk)
+				return nil
+			})
+		})
+	}
+}
+
+func Test_context_multipart_form_not_multipart(T *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("name=foo"))
+	req.Header.Set(HeaderContentType, MIMEApplicationForm)
+
+	serveContext(T, New(), req, func(c Context) error {
+		_, err := c.MultipartForm()
+		assert.ErrorIs(T, err, http.ErrNotMultipart)
+		return nil
+	})
+}
+
+func Test_context_cookies(T *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	req.AddCookie(&http.Cookie{Name: "theme", Value: "dark"})
+
+	rw := serveContext(T, New(), req, func(c Context) error {
+		cookie, err := c.Cookie("session")
+		require.NoError(T, err)
+		assert.Equal(T, "abc", cookie.Value)
+
+		_, err = c.Cookie("missing")
+		assert.ErrorIs(T, err, ErrCookieNotFound)
+
+		assert.Len(T, c.Cookies(), 2)
+
+		c.SetCookie(&http.Cookie{Name: "session", Value: "def", HttpOnly: true})
+		return c.NoContent()
+	})
+
+	assert.Equal(T, []string{"session=def; HttpOnly"}, rw.Header().Values(HeaderSetCookie))
+}
+
+type responseUser struct {
+	XMLName struct{} `json:"-" xml:"user"`
+	ID      int      `json:"id" xml:"id"`
+	Name    string   `json:"name" xml:"name"`
+}
+
+func Test_context_responses(T *testing.T) {
+	user := responseUser{ID: 1, Name: "foo"}
+
+	tests := map[string]struct {
+		handler     HandlerFunc
+		code        int
+		contentType string
+		body        string
+	}{
+		"json pretty": {
+			handler:     func(c Context) error { return c.JSONPretty(http.StatusCreated, user, "  ") },
+			code:        http.StatusCreated,
+			contentType: MIMEApplicationJSONCharsetUTF8,
+			body:        "{\n  \"id\": 1,\n  \"name\": \"foo\"\n}\n",
+		},
+		"json blob": {
+			handler:     func(c Context) error { return c.JSONBlob(http.StatusOK, []byte(`{"id":1}`)) },
+			code:        http.StatusOK,
+			contentType: MIMEApplicationJSONCharsetUTF8,
+			body:        `{"id":1}`,
+		},
+		"jsonp": {
+			handler:     func(c Context) error { return c.JSONP(http.StatusOK, "app.users_$1", user) },
+			code:        http.StatusOK,
+			contentType: MIMEApplicationJavaScriptCharsetUTF8,
+			body:        "/**/app.users_$1({\"id\":1,\"name\":\"foo\"}\n);",
+		},
+		"jsonp blob": {
+			handler:     func(c Context) error { return c.JSONPBlob(http.StatusAccepted, "callback", []byte(`{"id":1}`)) },
+			code:        http.StatusAccepted,
+			contentType: MIMEApplicationJavaScriptCharsetUTF8,
+			body:        `/**/callback({"id":1});`,
+		},
+		"xml": {
+			handler:     func(c Context) error { return c.XML(http.StatusOK, user) },
+			code:        http.StatusOK,
+			contentType: MIMEApplicationXMLCharsetUTF8,
+			body:        xmlHeader + "<user><id>1</id><name>foo</name></user>",
+		},
+		"xml pretty": {
+			handler:     func(c Context) error { return c.XMLPretty(http.StatusCreated, user, "  ") },
+			code:        http.StatusCreated,
+			contentType: MIMEApplicationXMLCharsetUTF8,
+			body:        xmlHeader + "<user>\n  <id>1</id>\n  <name>foo</name>\n</user>",
+		},
+		"xml blob": {
+			handler:     func(c Context) error { return c.XMLBlob(http.StatusOK, []byte("<user/>")) },
+			code:        http.StatusOK,
+			contentType: MIMEApplicationXMLCharsetUTF8,
+			body:        xmlHeader + "<user/>",
+		},
+	}
+
+	for name, test := range tests {
+		T.Run(name, func(T *testing.T) {
+			rw := serveContext(T, New(), httptest.NewRequest(http.MethodGet, "/", nil), test.handler)
+
+			assert.Equal(T, test.code, rw.Code)
+			assert.Equal(T, test.contentType, rw.Header().Get(HeaderContentType))
+			assert.Equal(T, test.body, rw.Body.String())
+		})
+	}
+}
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8"?>` + "\n"
+
+func Test_context_jsonp_invalid_callback(T *testing.T) {
+	callbacks := []string{
+		"",
+		"alert(1);foo",
+		"foo bar",
+		"<script>",
+		"foo..bar",
+		"foo.",
+		"1foo",
+		"foo[0]",
+		"foo ",
+		strings.Repeat("a", 129),
+	}
+
+	for _, callback := range callbacks {
+		T.Run(callback, func(T *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/?callback="+url.QueryEscape(callback), nil)
+			var err, blobErr error
+			rw := serveContext(T, New(), req, func(c Context) error {
+				err = c.JSONP(http.StatusOK, c.QueryParam("callback"), Map{"id": 1})
+				blobErr = c.JSONPBlob(http.StatusOK, c.QueryParam("callback"), []byte(`{}`))
+				return err
+			})
+
+			assert.ErrorIs(T, err, ErrInvalidJSONPCallback)
+			assert.ErrorIs(T, blobErr, ErrInvalidJSONPCallback)
+			assert.Equal(T, http.StatusBadRequest, rw.Code)
+			if callback != "" {
+				assert.NotContains(T, rw.Body.String(), callback)
+			}
+		})
+	}
+}
+
+type upperXmlEncoder struct{}
+
+func (upperXmlEncoder) Encode(c Context, i interface{}, indent string) error {
+	_, err := c.Response().Write([]byte("<CUSTOM/>"))
+	return err
+}
+
+func (upperXmlEncoder) Decode(c Context, i interface{}) error {
+	return nil
+}
+
+func Test_webapp_xml_encoder(T *testing.T) {
+	assert.Equal(T, DefaultXMLEncoder, New().XmlEncoder())
+
+	app := New(WithXmlEncoder(upperXmlEncoder{}))
+	rw := serveContext(T, app, httptest.NewRequest(http.MethodGet, "/", nil), func(c Context) error {
+		return c.XML(http.StatusOK, responseUser{})
+	})
+	assert.Equal(T, xmlHeader+"<CUSTOM/>", rw.Body.String())
+}
+
+func Test_default_xml_encoder_decode(T *testing.T) {
+	var user responseUser
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("<user><id>2</id><name>bar</name></user>"))
+	serveContext(T, New(), req, func(c Context) error {
+		require.NoError(T, DefaultXMLEncoder.Decode(c, &user))
+		return nil
+	})
+	assert.Equal(T, 2, user.ID)
+	assert.Equal(T, "bar", user.Name)
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("<user><id>2</id"))
+	serveContext(T, New(), req, func(c Context) error {
+		err := DefaultXMLEncoder.Decode(c, &user)
+		var he *HTTPError
+		require.ErrorAs(T, err, &he)
+		assert.Equal(T, http.StatusBadRequest, he.Code)
+		return nil
+	})
+}
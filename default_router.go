@@ -1,14 +1,26 @@
 package webapp
 
 import (
-	"fmt"
+	"io/fs"
 	"net/http"
 	"reflect"
 	"runtime"
+	"sort"
+	"strings"
 )
 
+// NewDefaultRouter returns a lightweight router. Routes without params are matched with a map lookup, routes with
+// params are matched in order of specificity, routes with a longer static prefix take precedence.
+//
+// The path supports `{name}` params, which match anything until the next closing character (`/`, `:`, `@`, `#`,
+// `;` or `|`), and a `*name` catch-all param at the end of the path.
+// A param can be constrained, `{id:int}`, `{id:uuid}` or a regular expression `{slug:[a-z-]+}`, a route is skipped
+// when the value does not satisfy the constraint. The last param of the path can be optional, `{page:int?}`.
 func NewDefaultRouter() Router {
-	r := &defaultRouter{}
+	r := &defaultRouter{
+		static:  make(map[string]map[string]*defaultRoute),
+		dynamic: make(map[string][]*defaultRoute),
+	}
 	r.defaultRouterGroup = &defaultRouterGroup{
 		router: r,
 	}
@@ -16,34 +28,427 @@ func NewDefaultRouter() Router {
 }
 
 type defaultRouter struct {
-	*defaultRouterGroup
-}
+	static   map[string]map[string]*defaultRoute
+	dynamic  map[string][]*defaultRoute
+	notFound NotFoundRoutes
 
-func (r *defaultRouter) RouteNotFound(path string, h HandlerFunc, m ...MiddlewareFunc) RouteInfo {
-	//TODO implement me
-	panic("implement me")
+	*defaultRouterGroup
 }
 
 func (r *defaultRouter) Handle(c Context) error {
+	pc := c.(ParamsContext)
+	ps := pc.ParamValuesPtr()
+
+	path := c.Path()
+	if route := r.find(c.Method(), path, ps, c.Request()); route != nil {
+		if route.vary != "" {
+			c.Response().Header().Add(HeaderVary, route.vary)
+		}
+		pc.SetParamNames(route.params...)
+		pc.SetCurrentRoute(route.routeInfo)
+		return route.handler(c)
+	}
+
+	if allow := r.allowed(path, c.Method()); allow != "" {
+		c.Response().Header().Set(HeaderAllow, allow)
+		// an OPTIONS request without an OPTIONS route is answered with the allowed methods
+		if c.Method() == http.MethodOptions {
+			return c.NoContent()
+		}
+		return ErrMethodNotAllowed
+	}
+
+	if handler, route, values := r.notFound.Find(path); handler != nil {
+		*ps = (*ps)[0:0]
+		for _, value := range values {
+			appendParam(ps, value)
+		}
+		pc.SetParamNames(route.Params()...)
+		pc.SetCurrentRoute(route)
+		return handler(c)
+	}
+
 	return c.NotFound()
+}
+
+func (r *defaultRouter) Lookup(method, path string) (HandlerFunc, RouteInfo, []string) {
+	var params []string
+	if route := r.find(method, path, &params, nil); route != nil {
+		return route.handler, route.routeInfo, params
+	}
+	return nil, nil, nil
+}
+
+// find returns the route matching the path, the param values are stored in params when not nil. When the request is
+// not nil the route matchers must match the request, otherwise the first registered route for the path is returned.
+func (r *defaultRouter) find(method, path string, params *[]string, req *http.Request) *defaultRoute {
+	if route, ok := r.static[method][path]; ok {
+		if route = route.selectRoute(req); route != nil {
+			if params != nil {
+				*params = (*params)[0:0]
+			}
+			return route
+		}
+	}
 
-	//internal lookup
-	//retreive route info
-	//retreive the path params extracted from
+	for _, route := range r.dynamic[method] {
+		if params != nil {
+			*params = (*params)[0:0]
+		}
+		if route.match(path, params) {
+			if route = route.selectRoute(req); route != nil {
+				return route
+			}
+		}
+	}
+	return nil
+}
+
+func (r *defaultRouter) add(method, path string, handler HandlerFunc, matchers []RouteMatcher, middleware ...MiddlewareFunc) RouteInfo {
+	if method == "" {
+		panic("method must not be empty")
+	}
+	if len(path) < 1 || path[0] != '/' {
+		panic("path must begin with '/' in path '" + path + "'")
+	}
+	if handler == nil {
+		panic("handler must not be nil")
+	}
+
+	routeInfo := newRouteInfo(method, path, handler)
+	handler = applyMiddleware(handler, middleware...)
+
+	// a path with an optional param is registered with and without the param
+	for _, pattern := range routeInfo.template.Variants() {
+		r.addPattern(method, &defaultRoute{
+			routeInfo: routeInfo,
+			handler:   handler,
+			pattern:   pattern,
+			tokens:    parseRouteTokens(pattern),
+			matchers:  matchers,
+			vary:      VaryHeaders(matchers),
+		})
+	}
 
+	return routeInfo
 }
 
-func (r *defaultRouter) Lookup(method, path string) (HandlerFunc, RouteInfo) {
-	//TODO implement me
-	panic("implement me")
+func (r *defaultRouter) addPattern(method string, route *defaultRoute) {
+	if route.isStatic() {
+		if r.static[method] == nil {
+			r.static[method] = make(map[string]*defaultRoute)
+		}
+		if existing, ok := r.static[method][route.pattern]; ok {
+			existing.addCandidate(route)
+			return
+		}
+		r.static[method][route.pattern] = route
+		return
+	}
+
+	routes := r.dynamic[method]
+	for _, existing := range routes {
+		if existing.pattern == route.pattern {
+			existing.addCandidate(route)
+			return
+		}
+	}
+	routes = append(routes, route)
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].moreSpecific(routes[j])
+	})
+	r.dynamic[method] = routes
+}
+
+// allowed returns the comma separated list of methods that have a route for the path
+func (r *defaultRouter) allowed(path, reqMethod string) string {
+	var allowed []string
+	for _, method := range Methods {
+		if method == reqMethod || method == http.MethodOptions {
+			continue
+		}
+		if route := r.find(method, path, nil, nil); route != nil {
+			allowed = append(allowed, method)
+		}
+	}
+
+	if len(allowed) == 0 {
+		return ""
+	}
+
+	allowed = append(allowed, http.MethodOptions)
+	sort.Strings(allowed)
+	return strings.Join(allowed, ", ")
+}
+
+type tokenKind uint8
+
+const (
+	literalToken tokenKind = iota
+	paramToken
+	catchAllToken
+)
+
+// routeToken is a part of the route path, a literal text or a param
+type routeToken struct {
+	kind tokenKind
+
+	// value is the literal text or the name of the param
+	value string
+
+	// separator is the closing character that precedes the catch-all value
+	separator byte
+
+	// spec of the param, the value must satisfy the constraint
+	spec ParamSpec
+}
+
+type defaultRoute struct {
+	*routeInfo
+	handler HandlerFunc
+
+	// pattern is the path matched by the route, for a path with an optional param it is the path with or without
+	// the param
+	pattern string
+	tokens  []routeToken
+
+	// matchers the request must satisfy, routes with the same pattern are candidates of the first registered route
+	matchers   []RouteMatcher
+	candidates []*defaultRoute
+	vary       string
+}
+
+// addCandidate adds a route with the same pattern but other matchers, the candidates are ordered by the number of
+// matchers so the most specific route is selected first. It panics when both routes have the same matchers.
+func (r *defaultRoute) addCandidate(route *defaultRoute) {
+	if r.candidates == nil {
+		r.candidates = []*defaultRoute{r}
+	}
+	for _, candidate := range r.candidates {
+		if SameMatchers(candidate.matchers, route.matchers) {
+			panic("a handle is already registered for path '" + route.pattern + "'")
+		}
+	}
+
+	r.candidates = append(r.candidates, route)
+	sort.SliceStable(r.candidates, func(i, j int) bool {
+		return len(r.candidates[i].matchers) > len(r.candidates[j].matchers)
+	})
+
+	var matchers []RouteMatcher
+	for _, candidate := range r.candidates {
+		matchers = append(matchers, candidate.matchers...)
+	}
+	vary := VaryHeaders(matchers)
+	for _, candidate := range r.candidates {
+		candidate.vary = vary
+	}
+}
+
+// selectRoute returns the most specific candidate matching the request, without a request the route itself is returned
+func (r *defaultRoute) selectRoute(req *http.Request) *defaultRoute {
+	if req == nil {
+		return r
+	}
+	if r.candidates == nil {
+		if MatchAll(r.matchers, req) {
+			return r
+		}
+		return nil
+	}
+	for _, candidate := range r.candidates {
+		if MatchAll(candidate.matchers, req) {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// match tries to match the path against the route tokens, the param values are appended to params when not nil
+func (r *defaultRoute) match(path string, params *[]string) bool {
+	for _, t := range r.tokens {
+		switch t.kind {
+		case literalToken:
+			if !strings.HasPrefix(path, t.value) {
+				return false
+			}
+			path = path[len(t.value):]
+
+		case paramToken:
+			// Find param end (either a closing character or path end)
+			end := 0
+			for end < len(path) && !isClosingCharacter(path[end]) {
+				end++
+			}
+			if end == 0 || !t.spec.Match(path[:end]) {
+				return false
+			}
+			appendParam(params, path[:end])
+			path = path[end:]
+
+		case catchAllToken:
+			if len(path) == 0 || path[0] != t.separator {
+				return false
+			}
+			appendParam(params, path)
+			path = ""
+		}
+	}
+	return path == ""
+}
+
+// moreSpecific reports if route r should be matched before the other route. Catch-all routes are matched last,
+// otherwise the route with the longest static prefix, then the most literal text and then the most constrained
+// params goes first.
+func (r *defaultRoute) moreSpecific(other *defaultRoute) bool {
+	if r.hasCatchAll() != other.hasCatchAll() {
+		return other.hasCatchAll()
+	}
+	if p, o := r.staticPrefixLen(), other.staticPrefixLen(); p != o {
+		return p > o
+	}
+	if l, o := r.literalLen(), other.literalLen(); l != o {
+		return l > o
+	}
+	return r.constraints() > other.constraints()
+}
+
+func (r *defaultRoute) isStatic() bool {
+	for _, t := range r.tokens {
+		if t.kind != literalToken {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *defaultRoute) constraints() int {
+	n := 0
+	for _, t := range r.tokens {
+		if t.spec.Constraint != "" {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *defaultRoute) hasCatchAll() bool {
+	return r.tokens[len(r.tokens)-1].kind == catchAllToken
+}
+
+func (r *defaultRoute) staticPrefixLen() int {
+	if r.tokens[0].kind == literalToken {
+		return len(r.tokens[0].value)
+	}
+	return 0
+}
+
+func (r *defaultRoute) literalLen() int {
+	n := 0
+	for _, t := range r.tokens {
+		if t.kind == literalToken {
+			n += len(t.value)
+		}
+	}
+	return n
+}
+
+// appendParam appends the value to the params, the params are grown if the preallocated size is too little
+func appendParam(params *[]string, value string) {
+	if params == nil {
+		return
+	}
+
+	i := len(*params)
+	if cap(*params) <= i {
+		dst := make([]string, i+1)
+		copy(dst, *params)
+		*params = dst
+	}
+
+	*params = (*params)[:i+1]
+	(*params)[i] = value
+}
+
+// parseRouteTokens splits the path in literal and param tokens
+func parseRouteTokens(path string) []routeToken {
+	var tokens []routeToken
+	literalStart := 0
+
+	addLiteral := func(end int) {
+		if end > literalStart {
+			tokens = append(tokens, routeToken{kind: literalToken, value: path[literalStart:end]})
+		}
+	}
+
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '{':
+			end := ParamEnd(path[i:])
+			if end < 0 {
+				panic("invalid path param defined, not properly closed with a '}' in path '" + path + "'")
+			}
+			spec := ParseParamSpec(path[i+1 : i+end])
+			if spec.Name == "" || strings.ContainsAny(spec.Name, "{}*/:@#;|?") {
+				panic("invalid path param name '" + spec.Name + "' in path '" + path + "'")
+			}
+			if len(tokens) > 0 && tokens[len(tokens)-1].kind != literalToken && literalStart == i {
+				panic("only one wildcard per path segment is allowed in path '" + path + "'")
+			}
+
+			addLiteral(i)
+			tokens = append(tokens, routeToken{kind: paramToken, value: spec.Name, spec: spec})
+			i += end
+			literalStart = i + 1
+
+		case '*':
+			name := path[i+1:]
+			if name == "" || strings.ContainsAny(name, "{}*/") {
+				panic("catch-all routes are only allowed at the end of the path in path '" + path + "'")
+			}
+			if i == 0 || !isClosingCharacter(path[i-1]) || literalStart == i {
+				panic("no / before catch-all in path '" + path + "'")
+			}
+
+			// The closing character before the catch-all is part of the catch-all value
+			addLiteral(i - 1)
+			return append(tokens, routeToken{kind: catchAllToken, value: name, separator: path[i-1]})
+		}
+	}
+
+	addLiteral(len(path))
+	return tokens
+}
+
+// isClosingChar are the allowed characters to close a path segment for a path variable
+func isClosingCharacter(c byte) bool {
+	return c == '/' || c == ':' || c == '@' || c == '#' || c == ';' || c == '|'
 }
 
 type defaultRouterGroup struct {
 	prefix     string
 	middleware []MiddlewareFunc
+	matchers   []RouteMatcher
 	router     *defaultRouter
 }
 
+func (g *defaultRouterGroup) RouteNotFound(path string, h HandlerFunc, m ...MiddlewareFunc) RouteInfo {
+	middleware := make([]MiddlewareFunc, 0, len(g.middleware)+len(m))
+	middleware = append(middleware, g.middleware...)
+	middleware = append(middleware, m...)
+
+	path = g.prefix + path
+	return g.router.notFound.Add(path, func(params []string) RouteInfo {
+		return &routeInfo{
+			name:     handlerName(h),
+			method:   RouteNotFoundMethod,
+			path:     path,
+			template: ParsePathTemplate(path),
+			params:   params,
+		}
+	}, h, middleware...)
+}
+
 func (g *defaultRouterGroup) Use(middleware ...MiddlewareFunc) {
 	g.middleware = append(g.middleware, middleware...)
 }
@@ -85,13 +490,32 @@ func (g *defaultRouterGroup) TRACE(path string, h HandlerFunc, m ...MiddlewareFu
 }
 
 func (g *defaultRouterGroup) Any(path string, handler HandlerFunc, middleware ...MiddlewareFunc) []RouteInfo {
-	//TODO implement me
-	panic("implement me")
+	return g.Match(Methods, path, handler, middleware...)
 }
 
 func (g *defaultRouterGroup) Match(methods []string, path string, handler HandlerFunc, middleware ...MiddlewareFunc) []RouteInfo {
-	//TODO implement me
-	panic("implement me")
+	m := make([]MiddlewareFunc, 0, len(g.middleware)+len(middleware))
+	m = append(m, g.middleware...)
+	m = append(m, middleware...)
+
+	routes := make([]RouteInfo, 0, len(methods))
+	for _, method := range methods {
+		route := g.router.add(method, g.prefix+path, handler, g.matchers, m...).(*routeInfo)
+		route.name = MethodQualifiedName(route.name, method)
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+func (g *defaultRouterGroup) Static(prefix string, fsys fs.FS, options ...StaticOption) []RouteInfo {
+	handler := StaticHandler(fsys, options...)
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	var routes []RouteInfo
+	if prefix != "" {
+		routes = g.Match(StaticMethods, prefix, handler)
+	}
+	return append(routes, g.Match(StaticMethods, prefix+"/*filepath", handler)...)
 }
 
 func (g *defaultRouterGroup) Group(prefix string, middleware ...MiddlewareFunc) RouteGroup {
@@ -102,6 +526,16 @@ func (g *defaultRouterGroup) Group(prefix string, middleware ...MiddlewareFunc)
 	return &defaultRouterGroup{
 		prefix:     g.prefix + prefix,
 		middleware: m,
+		matchers:   g.matchers,
+		router:     g.router,
+	}
+}
+
+func (g *defaultRouterGroup) With(matchers ...RouteMatcher) RouteGroup {
+	return &defaultRouterGroup{
+		prefix:     g.prefix,
+		middleware: g.middleware,
+		matchers:   append(g.matchers[:len(g.matchers):len(g.matchers)], matchers...),
 		router:     g.router,
 	}
 }
@@ -111,17 +545,17 @@ func (g *defaultRouterGroup) Add(method, path string, handler HandlerFunc, middl
 	m = append(m, g.middleware...)
 	m = append(m, middleware...)
 
-	return newRouteInfo(method, g.prefix+path, handler)
+	return g.router.add(method, g.prefix+path, handler, g.matchers, m...)
 }
 
-func newRouteInfo(method, path string, handler HandlerFunc) RouteInfo {
-	template, params := parsePath(path)
+func newRouteInfo(method, path string, handler HandlerFunc) *routeInfo {
+	template := ParsePathTemplate(path)
 	return &routeInfo{
 		name:     handlerName(handler),
 		method:   method,
 		path:     path,
 		template: template,
-		params:   params,
+		params:   template.Params(),
 	}
 }
 
@@ -129,8 +563,9 @@ type routeInfo struct {
 	name     string
 	method   string
 	path     string
-	template string
+	template *PathTemplate
 	params   []string
+	meta     RouteMeta
 }
 
 func (r *routeInfo) Name() string {
@@ -149,13 +584,36 @@ func (r *routeInfo) Params() []string {
 	return r.params
 }
 
-func (r *routeInfo) Reverse(params ...interface{}) string {
-	return fmt.Sprintf(r.template, params...)
+func (r *routeInfo) ParamSpecs() []ParamSpec {
+	return r.template.ParamSpecs()
+}
+
+func (r *routeInfo) Reverse(params ...interface{}) (string, error) {
+	return r.template.Expand(params...)
+}
+
+func (r *routeInfo) Named(name string) RouteInfo {
+	r.name = name
+	return r
+}
+
+func (r *routeInfo) Tag(tags ...string) RouteInfo {
+	r.meta.Tags = append(r.meta.Tags, tags...)
+	return r
+}
+
+func (r *routeInfo) Describe(summary string) RouteInfo {
+	r.meta.Summary = summary
+	return r
+}
+
+func (r *routeInfo) Deprecate() RouteInfo {
+	r.meta.Deprecated = true
+	return r
 }
 
-func parsePath(path string) (string, []string) {
-	// TODO implement me
-	return "", []string{}
+func (r *routeInfo) Meta() RouteMeta {
+	return r.meta
 }
 
 func handlerName(h HandlerFunc) string {
@@ -0,0 +1,558 @@
+package webapp
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_default_router_handler_gets_called(T *testing.T) {
+	app := New()
+
+	handlerCalled := false
+	app.GET("/test", func(c Context) error { handlerCalled = true; return nil })
+
+	req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.True(T, handlerCalled)
+	assert.Equal(T, 200, rw.Code)
+}
+
+func Test_default_router_404_http_error_on_no_matching_route(T *testing.T) {
+	app := New()
+
+	req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.Equal(T, 404, rw.Code)
+}
+
+func Test_default_router_405_http_error_on_other_method(T *testing.T) {
+	app := New()
+	app.GET("/test/{id}", func(c Context) error { return nil })
+	app.PUT("/test/{id}", func(c Context) error { return nil })
+
+	req, _ := http.NewRequest(http.MethodPost, "/test/1", nil)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.Equal(T, 405, rw.Code)
+	assert.Equal(T, "GET, OPTIONS, PUT", rw.Header().Get(HeaderAllow))
+}
+
+func Test_default_router_automatic_options(T *testing.T) {
+	app := New()
+	app.GET("/test/{id}", func(c Context) error { return nil })
+	app.PUT("/test/{id}", func(c Context) error { return nil })
+	app.GET("/custom", func(c Context) error { return nil })
+	app.OPTIONS("/custom", func(c Context) error { return c.String(http.StatusOK, "custom") })
+
+	req, _ := http.NewRequest(http.MethodOptions, "/test/1", nil)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.Equal(T, 204, rw.Code)
+	assert.Equal(T, "GET, OPTIONS, PUT", rw.Header().Get(HeaderAllow))
+
+	req, _ = http.NewRequest(http.MethodOptions, "/custom", nil)
+	rw = httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.Equal(T, 200, rw.Code)
+	assert.Equal(T, "custom", rw.Body.String())
+
+	req, _ = http.NewRequest(http.MethodOptions, "/unknown", nil)
+	rw = httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.Equal(T, 404, rw.Code)
+}
+
+func Test_default_router_group_route(T *testing.T) {
+	app := New()
+
+	handlerCalled := false
+	app.Group("/foo").Group("/bar").GET("/test", func(c Context) error { handlerCalled = true; return nil })
+
+	req, _ := http.NewRequest(http.MethodGet, "/foo/bar/test", nil)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.True(T, handlerCalled)
+	assert.Equal(T, 200, rw.Code)
+}
+
+func Test_default_router_middleware_gets_called(T *testing.T) {
+	var callstack []string
+	mockedMiddleware := func(name string) MiddlewareFunc {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(c Context) error {
+				callstack = append(callstack, name)
+				return next(c)
+			}
+		}
+	}
+
+	app := New()
+	app.Use(mockedMiddleware("main"))
+	app.Group("/foo", mockedMiddleware("foo")).
+		Group("/bar", mockedMiddleware("bar")).
+		GET("/test", func(c Context) error { callstack = append(callstack, "handler"); return nil }, mockedMiddleware("test"))
+
+	req, _ := http.NewRequest(http.MethodGet, "/foo/bar/test", nil)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.Equal(T, []string{"main", "foo", "bar", "test", "handler"}, callstack)
+	assert.Equal(T, 200, rw.Code)
+}
+
+func Test_default_router_no_middleware_gets_called_on_no_found_route(T *testing.T) {
+	var callstack []string
+	mockedMiddleware := func(name string) MiddlewareFunc {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(c Context) error {
+				callstack = append(callstack, name)
+				return next(c)
+			}
+		}
+	}
+
+	app := New()
+	app.Use(mockedMiddleware("main"))
+	app.Group("/foo", mockedMiddleware("foo")).
+		Group("/bar", mockedMiddleware("bar")).
+		GET("/test", func(c Context) error { callstack = append(callstack, "handler"); return nil }, mockedMiddleware("test"))
+
+	req, _ := http.NewRequest(http.MethodGet, "/foo/bar/baz", nil)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.Len(T, callstack, 0)
+	assert.Equal(T, 404, rw.Code)
+}
+
+func Test_default_router_path_params(T *testing.T) {
+	app := New()
+	app.GET("/test/{foo}/{bar}", func(c Context) error {
+		assert.Equal(T, []string{"foo", "bar"}, c.ParamNames())
+		assert.Equal(T, []string{"bar", "baz"}, c.ParamValues())
+		assert.Equal(T, "baz", c.Param("bar"))
+		assert.Equal(T, "/test/{foo}/{bar}", c.CurrentRoute().Path())
+		return nil
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/test/bar/baz", nil)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.Equal(T, 200, rw.Code)
+}
+
+func Test_default_router_path_params_with_action_keyword(T *testing.T) {
+	app := New()
+	app.GET("/test/{foo}:action", func(c Context) error {
+		assert.Equal(T, []string{"foo"}, c.ParamNames())
+		assert.Equal(T, []string{"bar"}, c.ParamValues())
+		return nil
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/test/bar:action", nil)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.Equal(T, 200, rw.Code)
+}
+
+func Test_default_router_path_params_with_action_keyword_no_override(T *testing.T) {
+	app := New()
+	app.GET("/test/{foo}", func(c Context) error {
+		assert.Equal(T, []string{"foo"}, c.ParamNames())
+		assert.Equal(T, []string{"aabc-id-here"}, c.ParamValues())
+		return nil
+	})
+
+	app.GET("/test/{foo}:action", func(c Context) error {
+		assert.Fail(T, "should not be called")
+		return nil
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/test/aabc-id-here", nil)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.Equal(T, 200, rw.Code)
+}
+
+func Test_default_router_path_params_with_main_no_override_action_keyword(T *testing.T) {
+	app := New()
+	app.GET("/test/{foo}", func(c Context) error {
+		assert.Fail(T, "should not be called")
+		return nil
+	})
+
+	app.GET("/test/{foo}:action", func(c Context) error {
+		assert.Equal(T, []string{"foo"}, c.ParamNames())
+		assert.Equal(T, []string{"aabc-id-here"}, c.ParamValues())
+		return nil
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/test/aabc-id-here:action", nil)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.Equal(T, 200, rw.Code)
+}
+
+func Test_default_router_catch_all(T *testing.T) {
+	app := New()
+	app.GET("/files/*filepath", func(c Context) error {
+		return c.String(http.StatusOK, c.Param("filepath"))
+	})
+
+	tests := map[string]struct {
+		path string
+		code int
+		body string
+	}{
+		"root":      {path: "/files/", code: 200, body: "/"},
+		"file":      {path: "/files/LICENSE", code: 200, body: "/LICENSE"},
+		"nested":    {path: "/files/templates/article.html", code: 200, body: "/templates/article.html"},
+		"no prefix": {path: "/files", code: 404},
+	}
+
+	for name, test := range tests {
+		T.Run(name, func(T *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, test.path, nil)
+			rw := httptest.NewRecorder()
+			app.ServeHTTP(rw, req)
+
+			assert.Equal(T, test.code, rw.Code)
+			if test.code == 200 {
+				assert.Equal(T, test.body, rw.Body.String())
+			}
+		})
+	}
+}
+
+func Test_default_router_static_routes_take_precedence(T *testing.T) {
+	app := New()
+	app.GET("/users/{id}", func(c Context) error { return c.String(200, "param") })
+	app.GET("/users/new", func(c Context) error { return c.String(200, "static") })
+	app.GET("/users/{id}/edit", func(c Context) error { return c.String(200, "param edit") })
+	app.GET("/users/new/{step}", func(c Context) error { return c.String(200, "static step") })
+	app.GET("/users/*rest", func(c Context) error { return c.String(200, "catch-all") })
+
+	tests := map[string]string{
+		"/users/new":       "static",
+		"/users/123":       "param",
+		"/users/123/edit":  "param edit",
+		"/users/new/edit":  "static step",
+		"/users/123/other": "catch-all",
+	}
+
+	for path, expected := range tests {
+		T.Run(path, func(T *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, path, nil)
+			rw := httptest.NewRecorder()
+			app.ServeHTTP(rw, req)
+
+			assert.Equal(T, expected, rw.Body.String())
+		})
+	}
+}
+
+func Test_default_router_lookup(T *testing.T) {
+	r := NewDefaultRouter()
+	r.GET("/test/{id}", func(c Context) error { return nil })
+
+	h, routeInfo, params := r.Lookup(http.MethodGet, "/test/123")
+	require.NotNil(T, h)
+	assert.Equal(T, []string{"123"}, params)
+	assert.Equal(T, "/test/{id}", routeInfo.Path())
+	assert.Equal(T, []string{"id"}, routeInfo.Params())
+	uri, err := routeInfo.Reverse(456)
+	require.NoError(T, err)
+	assert.Equal(T, "/test/456", uri)
+
+	h, routeInfo, params = r.Lookup(http.MethodPost, "/test/123")
+	assert.Nil(T, h)
+	assert.Nil(T, routeInfo)
+	assert.Nil(T, params)
+}
+
+func Test_default_router_invalid_paths(T *testing.T) {
+	tests := map[string]string{
+		"no leading slash":       "test",
+		"unclosed param":         "/test/{id",
+		"empty param":            "/test/{}",
+		"adjacent params":        "/test/{id}{name}",
+		"catch-all not at end":   "/test/*rest/foo",
+		"catch-all without name": "/test/*",
+		"no slash before catch":  "/test*rest",
+	}
+
+	for name, path := range tests {
+		T.Run(name, func(T *testing.T) {
+			assert.Panics(T, func() {
+				NewDefaultRouter().GET(path, func(c Context) error { return nil })
+			})
+		})
+	}
+
+	assert.Panics(T, func() {
+		r := NewDefaultRouter()
+		r.GET("/test/{id}", func(c Context) error { return nil })
+		r.GET("/test/{id}", func(c Context) error { return nil })
+	}, "duplicate route")
+}
+
+func Test_default_router_route_not_found(T *testing.T) {
+	var callstack []string
+	app := New()
+	app.GET("/api/users", func(c Context) error { return c.String(http.StatusOK, "users") })
+	app.RouteNotFound("/*", func(c Context) error { return c.String(http.StatusNotFound, "root") })
+	app.RouteNotFound("/app/*", func(c Context) error { return c.HTML(http.StatusNotFound, "<h1>app</h1>") })
+	app.Group("/api", func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			callstack = append(callstack, "api")
+			return next(c)
+		}
+	}).RouteNotFound("/*rest", func(c Context) error {
+		return c.JSON(http.StatusNotFound, map[string]string{"rest": c.Param("rest")})
+	})
+	app.RouteNotFound("/exact", func(c Context) error { return c.String(http.StatusNotFound, "exact") })
+
+	tests := map[string]struct {
+		path string
+		code int
+		body string
+	}{
+		"matched route":       {path: "/api/users", code: 200, body: "users"},
+		"root scope":          {path: "/foo", code: 404, body: "root"},
+		"app scope":           {path: "/app/foo/bar", code: 404, body: "<h1>app</h1>"},
+		"app prefix":          {path: "/app", code: 404, body: "<h1>app</h1>"},
+		"api scope with name": {path: "/api/foo/bar", code: 404, body: "{\"rest\":\"/foo/bar\"}\n"},
+		"static":              {path: "/exact", code: 404, body: "exact"},
+		"static no prefix":    {path: "/exact/foo", code: 404, body: "root"},
+	}
+
+	for name, test := range tests {
+		T.Run(name, func(T *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, test.path, nil)
+			rw := httptest.NewRecorder()
+			app.ServeHTTP(rw, req)
+
+			assert.Equal(T, test.code, rw.Code)
+			assert.Equal(T, test.body, rw.Body.String())
+		})
+	}
+
+	assert.Equal(T, []string{"api"}, callstack)
+}
+
+func Test_default_router_route_not_found_is_registered(T *testing.T) {
+	app := New()
+	app.RouteNotFound("/app/*", func(c Context) error { return nil })
+
+	routes := app.Routes().All()
+	require.Len(T, routes, 1)
+	assert.Equal(T, RouteNotFoundMethod, routes[0].Method())
+	assert.Equal(T, "/app/*", routes[0].Path())
+}
+
+func Test_default_router_route_not_found_invalid_paths(T *testing.T) {
+	tests := map[string]string{
+		"no leading slash":      "app/*",
+		"param":                 "/app/{id}/*",
+		"catch-all not at end":  "/app/*rest/foo",
+		"no slash before catch": "/app*",
+	}
+
+	for name, path := range tests {
+		T.Run(name, func(T *testing.T) {
+			assert.Panics(T, func() {
+				NewDefaultRouter().RouteNotFound(path, func(c Context) error { return nil })
+			})
+		})
+	}
+}
+
+func Test_default_router_any(T *testing.T) {
+	calls := 0
+	app := New()
+	app.Group("/hooks", func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			calls++
+			return next(c)
+		}
+	}).Any("/{id}", func(c Context) error { return c.String(http.StatusOK, c.Param("id")) })
+
+	for _, method := range Methods {
+		T.Run(method, func(T *testing.T) {
+			calls = 0
+			req, _ := http.NewRequest(method, "/hooks/github", nil)
+			rw := httptest.NewRecorder()
+			app.ServeHTTP(rw, req)
+
+			assert.Equal(T, 200, rw.Code)
+			assert.Equal(T, "github", rw.Body.String())
+			assert.Equal(T, 1, calls)
+		})
+	}
+
+	assert.Len(T, app.Routes().All(), len(Methods))
+}
+
+func Test_default_router_match(T *testing.T) {
+	handler := func(c Context) error { return c.NoContent() }
+	app := New()
+	routeInfos := app.Match([]string{http.MethodGet, http.MethodPost}, "/proxy", handler)
+
+	require.Len(T, routeInfos, 2)
+	assert.Equal(T, http.MethodGet, routeInfos[0].Method())
+	assert.Equal(T, http.MethodPost, routeInfos[1].Method())
+	assert.Equal(T, MethodQualifiedName(handlerName(handler), http.MethodGet), routeInfos[0].Name())
+	assert.Equal(T, routeInfos[1], app.Routes().Get(MethodQualifiedName(handlerName(handler), http.MethodPost)))
+
+	req, _ := http.NewRequest(http.MethodPut, "/proxy", nil)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.Equal(T, 405, rw.Code)
+	assert.Equal(T, "GET, OPTIONS, POST", rw.Header().Get(HeaderAllow))
+}
+
+func Test_default_router_param_constraints(T *testing.T) {
+	reply := func(name string) HandlerFunc {
+		return func(c Context) error {
+			return c.String(http.StatusOK, name+":"+c.Param(c.ParamNames()[len(c.ParamNames())-1]))
+		}
+	}
+
+	app := New()
+	app.GET("/users/{name}", reply("name"))
+	app.GET("/users/{id:int}", reply("int"))
+	app.GET("/users/{id:uuid}", reply("uuid"))
+	app.GET("/tags/{slug:[a-z-]+}", reply("slug"))
+	app.GET("/codes/{code:[0-9]{3}}", reply("code"))
+	app.GET("/posts/{page:int?}", reply("page"))
+
+	tests := map[string]struct {
+		path string
+		code int
+		body string
+	}{
+		"int":                    {path: "/users/12", code: 200, body: "int:12"},
+		"uuid":                   {path: "/users/6ba7b810-9dad-11d1-80b4-00c04fd430c8", code: 200, body: "uuid:6ba7b810-9dad-11d1-80b4-00c04fd430c8"},
+		"falls through":          {path: "/users/john", code: 200, body: "name:john"},
+		"regex":                  {path: "/tags/go-lang", code: 200, body: "slug:go-lang"},
+		"regex mismatch":         {path: "/tags/Go", code: 404},
+		"regex with braces":      {path: "/codes/404", code: 200, body: "code:404"},
+		"regex with braces miss": {path: "/codes/4044", code: 404},
+		"optional":               {path: "/posts/2", code: 200, body: "page:2"},
+		"optional omitted":       {path: "/posts", code: 200, body: "page:"},
+		"optional mismatch":      {path: "/posts/last", code: 404},
+	}
+
+	for name, test := range tests {
+		T.Run(name, func(T *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, test.path, nil)
+			rw := httptest.NewRecorder()
+			app.ServeHTTP(rw, req)
+
+			assert.Equal(T, test.code, rw.Code)
+			if test.code == 200 {
+				assert.Equal(T, test.body, rw.Body.String())
+			}
+		})
+	}
+
+	assert.Panics(T, func() {
+		app.GET("/admins/{id:int?}/edit", reply("admin"))
+	}, "optional param not last")
+}
+
+// paramsRoute returns a route path with n params and a request path matching it
+func paramsRoute(n int) (string, string) {
+	route, path := strings.Builder{}, strings.Builder{}
+	route.WriteString("/params")
+	path.WriteString("/params")
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&route, "/{p%d}", i)
+		fmt.Fprintf(&path, "/v%d", i)
+	}
+	return route.String(), path.String()
+}
+
+func Test_default_router_param_values_preallocated(T *testing.T) {
+	app := New()
+	app.GET("/short/{id}", func(c Context) error { return nil })
+
+	// the first request puts a context sized for a single param in the pool
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/short/1", nil))
+	assert.Equal(T, 1, app.(*webapp).maxParams)
+
+	route, path := paramsRoute(8)
+	var values []string
+	app.GET(route, func(c Context) error {
+		values = c.ParamValues()
+		return nil
+	})
+	assert.Equal(T, 8, app.(*webapp).maxParams)
+
+	rw = httptest.NewRecorder()
+	app.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, path, nil))
+	assert.Equal(T, []string{"v1", "v2", "v3", "v4", "v5", "v6", "v7", "v8"}, values)
+
+	c := app.(*webapp).newContext().(*context)
+	assert.Len(T, c.paramValues, 8)
+}
+
+func Test_default_router_params_no_allocations(T *testing.T) {
+	if raceEnabled {
+		T.Skip("the pooled contexts are dropped at random by the race detector")
+	}
+	for n := 1; n <= 8; n++ {
+		app := New()
+		route, path := paramsRoute(n)
+		app.GET(route, func(c Context) error { return nil })
+
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rw := httptest.NewRecorder()
+		app.ServeHTTP(rw, req)
+
+		allocs := testing.AllocsPerRun(100, func() {
+			app.ServeHTTP(rw, req)
+		})
+		assert.Zero(T, allocs, "allocations for %d params", n)
+	}
+}
+
+func Benchmark_default_router_params(b *testing.B) {
+	for n := 1; n <= 8; n++ {
+		b.Run(fmt.Sprintf("%d params", n), func(b *testing.B) {
+			app := New()
+			route, path := paramsRoute(n)
+			app.GET(route, func(c Context) error { return nil })
+
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			rw := httptest.NewRecorder()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				app.ServeHTTP(rw, req)
+			}
+		})
+	}
+}
@@ -1,5 +1,11 @@
 package webapp
 
+import (
+	"io"
+	"net/http"
+	"strings"
+)
+
 // JSONEncoding is the interface that encodes and decodes JSON to and from interfaces.
 type JSONEncoding interface {
 	Encode(c Context, i interface{}, indent string) error
@@ -11,3 +17,181 @@ type XMLEncoding interface {
 	Encode(c Context, i interface{}, indent string) error
 	Decode(c Context, i interface{}) error
 }
+
+// Encoding is the interface that encodes and decodes the bodies of a media type, like json, msgpack or protobuf.
+// The encodings registered with WithEncoding are used by the binder to decode the request bodies and by
+// Context.Negotiate to encode the responses. The json and xml encodings are registered by default.
+type Encoding interface {
+	// MediaType returns the media type of the encoded bodies, like `application/msgpack`
+	MediaType() string
+
+	Encode(w io.Writer, i interface{}) error
+	Decode(r io.Reader, i interface{}) error
+}
+
+// IndentEncoding is an Encoding that can pretty print, used by Context.JSONPretty and Context.XMLPretty. Without
+// indent support the value is encoded without indentation.
+type IndentEncoding interface {
+	Encoding
+
+	EncodeIndent(w io.Writer, i interface{}, indent string) error
+}
+
+// SelectiveEncoding is an Encoding that can only encode some values, like protobuf which only encodes proto messages.
+// Context.Negotiate does not offer the media type for the values the encoding cannot encode.
+type SelectiveEncoding interface {
+	Encoding
+
+	CanEncode(i interface{}) bool
+}
+
+// encodings are the registered encodings in order of preference
+type encodings []Encoding
+
+func defaultEncodings() encodings {
+	return encodings{DefaultJSONEncoding, DefaultXMLEncoding}
+}
+
+// set replaces the encoding of the same media type or adds the encoding with the lowest preference
+func (e encodings) set(encoding Encoding) encodings {
+	for i := range e {
+		if strings.EqualFold(e[i].MediaType(), encoding.MediaType()) {
+			e[i] = encoding
+			return e
+		}
+	}
+	return append(e, encoding)
+}
+
+// get returns the encoding for the content type, the parameters of the content type are ignored
+func (e encodings) get(contentType string) Encoding {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(mediaType)
+	for _, encoding := range e {
+		if strings.EqualFold(encoding.MediaType(), mediaType) {
+			return encoding
+		}
+	}
+	return nil
+}
+
+// setEncoder registers the JSONEncoding or XMLEncoding as the encoding of the media type
+func (e encodings) setEncoder(mediaType string, encoder JSONEncoding) encodings {
+	if ee, ok := encoder.(encodingEncoder); ok && strings.EqualFold(ee.encoding.MediaType(), mediaType) {
+		return e.set(ee.encoding)
+	}
+	return e.set(contextEncoding{mediaType: mediaType, encoder: encoder})
+}
+
+// encoder returns the encoding of the media type as JSONEncoding or XMLEncoding, a response encoder registered with
+// WithResponseEncoder is skipped as it only encodes the negotiated responses
+func (e encodings) encoder(mediaType string) JSONEncoding {
+	encoding := e.get(mediaType)
+	if re, ok := encoding.(responseEncoding); ok {
+		encoding = re.encoding
+	}
+
+	switch encoding := encoding.(type) {
+	case nil:
+		return nil
+	case contextEncoding:
+		return encoding.encoder
+	}
+	return encodingEncoder{encoding: encoding}
+}
+
+// canEncode reports if the encoding can encode the value, only a SelectiveEncoding can refuse a value
+func canEncode(encoding Encoding, i interface{}) bool {
+	if se, ok := encoding.(SelectiveEncoding); ok {
+		return se.CanEncode(i)
+	}
+	return true
+}
+
+// encodeIndent encodes the value with the indent when the encoding supports indentation
+func encodeIndent(encoding Encoding, w io.Writer, i interface{}, indent string) error {
+	if ie, ok := encoding.(IndentEncoding); ok && indent != "" {
+		return ie.EncodeIndent(w, i, indent)
+	}
+	return encoding.Encode(w, i)
+}
+
+// bindEncoding binds the encodings that need a context to the context
+func bindEncoding(encoding Encoding, c Context) Encoding {
+	switch e := encoding.(type) {
+	case contextEncoding:
+		e.c = c
+		return e
+	case responseEncoding:
+		e.encoding = bindEncoding(e.encoding, c)
+		return e
+	}
+	return encoding
+}
+
+// encodingEncoder adapts an Encoding to a JSONEncoding or XMLEncoding
+type encodingEncoder struct {
+	encoding Encoding
+}
+
+func (e encodingEncoder) Encode(c Context, i interface{}, indent string) error {
+	return encodeIndent(e.encoding, c.Response(), i, indent)
+}
+
+func (e encodingEncoder) Decode(c Context, i interface{}) error {
+	return e.encoding.Decode(c.Request().Body, i)
+}
+
+// contextEncoding adapts a JSONEncoding or XMLEncoding to an Encoding. The encoder writes to the response and reads
+// the request body of the context, so it only encodes and decodes when bound to a context by Context.Encoding.
+type contextEncoding struct {
+	mediaType string
+	encoder   JSONEncoding
+	c         Context
+}
+
+func (e contextEncoding) MediaType() string {
+	return e.mediaType
+}
+
+// Encode encodes the value to the response of the bound context, the writer is ignored
+func (e contextEncoding) Encode(_ io.Writer, i interface{}) error {
+	if e.c == nil {
+		return ErrContextRequired
+	}
+	return e.encoder.Encode(e.c, i, "")
+}
+
+// Decode decodes the request body of the bound context, the reader is ignored
+func (e contextEncoding) Decode(_ io.Reader, i interface{}) error {
+	if e.c == nil {
+		return ErrContextRequired
+	}
+	return e.encoder.Decode(e.c, i)
+}
+
+// responseEncoding is the encoding of a ResponseEncoder registered with WithResponseEncoder, the request bodies are
+// decoded with the encoding that was registered for the media type before
+type responseEncoding struct {
+	mediaType string
+	encoder   ResponseEncoder
+	encoding  Encoding
+}
+
+func (e responseEncoding) MediaType() string {
+	return e.mediaType
+}
+
+func (e responseEncoding) Encode(w io.Writer, i interface{}) error {
+	if e.encoding == nil {
+		return ErrContextRequired
+	}
+	return e.encoding.Encode(w, i)
+}
+
+func (e responseEncoding) Decode(r io.Reader, i interface{}) error {
+	if e.encoding == nil {
+		return NewHTTPError(http.StatusUnsupportedMediaType)
+	}
+	return e.encoding.Decode(r, i)
+}
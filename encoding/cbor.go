@@ -0,0 +1,28 @@
+package encoding
+
+import (
+	"io"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/mbict/webapp"
+)
+
+// CBOR returns the encoding for `application/cbor` bodies, struct fields are mapped with the `cbor` tag or the `json`
+// tag when no cbor tag is set
+func CBOR() webapp.Encoding {
+	return cborEncoding{}
+}
+
+type cborEncoding struct{}
+
+func (cborEncoding) MediaType() string {
+	return webapp.MIMEApplicationCBOR
+}
+
+func (cborEncoding) Encode(w io.Writer, i interface{}) error {
+	return cbor.NewEncoder(w).Encode(i)
+}
+
+func (cborEncoding) Decode(r io.Reader, i interface{}) error {
+	return cbor.NewDecoder(r).Decode(i)
+}
@@ -0,0 +1,132 @@
+package encoding
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mbict/webapp"
+	"github.com/mbict/webapp/binder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type user struct {
+	ID   int    `msgpack:"id" cbor:"id"`
+	Name string `msgpack:"name" cbor:"name"`
+}
+
+func Test_encodings(T *testing.T) {
+	tests := map[string]struct {
+		encoding webapp.Encoding
+		request  interface{}
+		newBody  func() interface{}
+	}{
+		"msgpack":  {encoding: Msgpack(), request: &user{ID: 1, Name: "foo"}, newBody: func() interface{} { return &user{} }},
+		"cbor":     {encoding: CBOR(), request: &user{ID: 1, Name: "foo"}, newBody: func() interface{} { return &user{} }},
+		"protobuf": {encoding: Protobuf(), request: wrapperspb.String("foo"), newBody: func() interface{} { return &wrapperspb.StringValue{} }},
+	}
+
+	for name, test := range tests {
+		T.Run(name, func(T *testing.T) {
+			body := &bytes.Buffer{}
+			require.NoError(T, test.encoding.Encode(body, test.request))
+
+			app := webapp.New(webapp.WithBinder(binder.New()), webapp.WithEncoding(test.encoding))
+			app.POST("/", func(c webapp.Context) error {
+				v := test.newBody()
+				if err := c.BindBody(v); err != nil {
+					return err
+				}
+				return c.Negotiate(http.StatusCreated, v)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/", body)
+			req.Header.Set(webapp.HeaderContentType, test.encoding.MediaType()+"; charset=utf-8")
+			req.Header.Set(webapp.HeaderAccept, test.encoding.MediaType())
+			rw := httptest.NewRecorder()
+			app.ServeHTTP(rw, req)
+
+			assert.Equal(T, http.StatusCreated, rw.Code)
+			assert.Equal(T, test.encoding.MediaType(), rw.Header().Get(webapp.HeaderContentType))
+
+			response := test.newBody()
+			require.NoError(T, test.encoding.Decode(rw.Body, response))
+			if m, ok := response.(proto.Message); ok {
+				assert.True(T, proto.Equal(test.request.(proto.Message), m))
+			} else {
+				assert.Equal(T, test.request, response)
+			}
+		})
+	}
+}
+
+func Test_protobuf_not_proto_message(T *testing.T) {
+	assert.ErrorIs(T, Protobuf().Encode(&bytes.Buffer{}, user{}), ErrNotProtoMessage)
+	assert.ErrorIs(T, Protobuf().Decode(&bytes.Buffer{}, &user{}), ErrNotProtoMessage)
+}
+
+func Test_protobuf_negotiate_not_proto_message(T *testing.T) {
+	tests := map[string]struct {
+		accept      string
+		code        int
+		contentType string
+	}{
+		"falls back to json": {accept: "application/protobuf, application/json;q=0.5", code: http.StatusOK, contentType: webapp.MIMEApplicationJSONCharsetUTF8},
+		"not acceptable":     {accept: "application/protobuf", code: http.StatusNotAcceptable},
+	}
+
+	for name, test := range tests {
+		T.Run(name, func(T *testing.T) {
+			app := webapp.New(webapp.WithEncoding(Protobuf()))
+			app.GET("/", func(c webapp.Context) error {
+				return c.Negotiate(http.StatusOK, user{ID: 1, Name: "foo"})
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(webapp.HeaderAccept, test.accept)
+			rw := httptest.NewRecorder()
+			app.ServeHTTP(rw, req)
+
+			assert.Equal(T, test.code, rw.Code)
+			if test.contentType != "" {
+				assert.Equal(T, test.contentType, rw.Header().Get(webapp.HeaderContentType))
+			}
+		})
+	}
+}
+
+// upperJSONEncoding is a json encoding that decodes the names in upper case
+type upperJSONEncoding struct {
+	webapp.Encoding
+}
+
+func (e upperJSONEncoding) Decode(r io.Reader, i interface{}) error {
+	if err := e.Encoding.Decode(r, i); err != nil {
+		return err
+	}
+	u := i.(*user)
+	u.Name = strings.ToUpper(u.Name)
+	return nil
+}
+
+func Test_binder_replaced_json_encoding(T *testing.T) {
+	app := webapp.New(webapp.WithBinder(binder.New()), webapp.WithEncoding(upperJSONEncoding{webapp.DefaultJSONEncoding}))
+	var u user
+	app.POST("/", func(c webapp.Context) error {
+		return c.BindBody(&u)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"ID":1,"Name":"foo"}`))
+	req.Header.Set(webapp.HeaderContentType, webapp.MIMEApplicationJSONCharsetUTF8)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.Equal(T, http.StatusOK, rw.Code)
+	assert.Equal(T, user{ID: 1, Name: "FOO"}, u)
+}
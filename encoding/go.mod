@@ -0,0 +1,22 @@
+module github.com/mbict/webapp/encoding
+
+go 1.23
+
+replace github.com/mbict/webapp => ./../
+
+require (
+	github.com/fxamacker/cbor/v2 v2.9.0
+	github.com/mbict/webapp v0.0.0-20230630153911-700d05ab545f
+	github.com/stretchr/testify v1.8.2
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+	google.golang.org/protobuf v1.36.6
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
+	golang.org/x/exp v0.0.0-20240909161429-701f63a606c0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,27 @@
+package encoding
+
+import (
+	"io"
+
+	"github.com/mbict/webapp"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Msgpack returns the encoding for `application/msgpack` bodies, struct fields are mapped with the `msgpack` tag
+func Msgpack() webapp.Encoding {
+	return msgpackEncoding{}
+}
+
+type msgpackEncoding struct{}
+
+func (msgpackEncoding) MediaType() string {
+	return webapp.MIMEApplicationMsgpack
+}
+
+func (msgpackEncoding) Encode(w io.Writer, i interface{}) error {
+	return msgpack.NewEncoder(w).Encode(i)
+}
+
+func (msgpackEncoding) Decode(r io.Reader, i interface{}) error {
+	return msgpack.NewDecoder(r).Decode(i)
+}
@@ -0,0 +1,54 @@
+package encoding
+
+import (
+	"errors"
+	"io"
+
+	"github.com/mbict/webapp"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrNotProtoMessage is returned when a value that is not a proto.Message is encoded or decoded with protobuf
+var ErrNotProtoMessage = errors.New("value is not a proto.Message")
+
+// Protobuf returns the encoding for `application/protobuf` bodies, only values implementing proto.Message can be
+// encoded and decoded. Context.Negotiate does not offer protobuf for the other values.
+func Protobuf() webapp.Encoding {
+	return protobufEncoding{}
+}
+
+type protobufEncoding struct{}
+
+func (protobufEncoding) MediaType() string {
+	return webapp.MIMEApplicationProtobuf
+}
+
+func (protobufEncoding) CanEncode(i interface{}) bool {
+	_, ok := i.(proto.Message)
+	return ok
+}
+
+func (protobufEncoding) Encode(w io.Writer, i interface{}) error {
+	m, ok := i.(proto.Message)
+	if !ok {
+		return ErrNotProtoMessage
+	}
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+func (protobufEncoding) Decode(r io.Reader, i interface{}) error {
+	m, ok := i.(proto.Message)
+	if !ok {
+		return ErrNotProtoMessage
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(b, m)
+}
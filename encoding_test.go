@@ -0,0 +1,143 @@
+package webapp
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// textEncoding encodes the values with the fmt package
+type textEncoding struct {
+	mediaType string
+}
+
+func (e textEncoding) MediaType() string {
+	return e.mediaType
+}
+
+func (e textEncoding) Encode(w io.Writer, i interface{}) error {
+	_, err := fmt.Fprintf(w, "%s:%v", e.mediaType, i)
+	return err
+}
+
+func (e textEncoding) Decode(r io.Reader, i interface{}) error {
+	_, err := fmt.Fscan(r, i)
+	return err
+}
+
+// intEncoding is a textEncoding that only encodes integers
+type intEncoding struct {
+	textEncoding
+}
+
+func (intEncoding) CanEncode(i interface{}) bool {
+	_, ok := i.(int)
+	return ok
+}
+
+func Test_context_encoding(T *testing.T) {
+	msgpack := textEncoding{mediaType: MIMEApplicationMsgpack}
+	cbor := textEncoding{mediaType: MIMEApplicationCBOR}
+	replaced := textEncoding{mediaType: "Application/CBOR"}
+	app := New(WithEncoding(msgpack, cbor), WithEncoding(replaced))
+
+	assert.Equal(T, []Encoding{DefaultJSONEncoding, DefaultXMLEncoding, msgpack, replaced}, app.Encodings())
+	serveContext(T, app, httptest.NewRequest(http.MethodGet, "/", nil), func(c Context) error {
+		assert.Equal(T, msgpack, c.Encoding(MIMEApplicationMsgpack))
+		assert.Equal(T, msgpack, c.Encoding("application/MSGPACK; charset=utf-8"))
+		assert.Equal(T, replaced, c.Encoding(MIMEApplicationCBOR))
+		assert.Nil(T, c.Encoding(MIMEApplicationProtobuf))
+		assert.Nil(T, c.Encoding(""))
+		return nil
+	})
+}
+
+func Test_context_negotiate_encoding(T *testing.T) {
+	app := New(WithEncoding(textEncoding{mediaType: MIMEApplicationMsgpack}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderAccept, "application/json;q=0.5, application/msgpack")
+
+	rw := serveContext(T, app, req, func(c Context) error {
+		return c.Negotiate(http.StatusCreated, 42)
+	})
+
+	assert.Equal(T, http.StatusCreated, rw.Code)
+	assert.Equal(T, MIMEApplicationMsgpack, rw.Header().Get(HeaderContentType))
+	assert.Equal(T, "application/msgpack:42", rw.Body.String())
+}
+
+func Test_context_json_pretty_encoding(T *testing.T) {
+	// an encoding without indent support encodes without indentation
+	app := New(WithEncoding(textEncoding{mediaType: MIMEApplicationJSON}))
+	rw := serveContext(T, app, httptest.NewRequest(http.MethodGet, "/", nil), func(c Context) error {
+		return c.JSONPretty(http.StatusOK, 42, "  ")
+	})
+
+	assert.Equal(T, MIMEApplicationJSONCharsetUTF8, rw.Header().Get(HeaderContentType))
+	assert.Equal(T, "application/json:42", rw.Body.String())
+}
+
+// upperJsonEncoder is a JSONEncoding that writes and reads upper case strings
+type upperJsonEncoder struct{}
+
+func (upperJsonEncoder) Encode(c Context, i interface{}, indent string) error {
+	_, err := fmt.Fprintf(c.Response(), "%s", strings.ToUpper(i.(string)))
+	return err
+}
+
+func (upperJsonEncoder) Decode(c Context, i interface{}) error {
+	b, err := io.ReadAll(c.Request().Body)
+	*i.(*string) = strings.ToUpper(string(b))
+	return err
+}
+
+func Test_context_json_encoder(T *testing.T) {
+	app := New(WithJsonEncoder(upperJsonEncoder{}))
+	assert.Equal(T, upperJsonEncoder{}, app.JsonEncoder())
+	assert.Equal(T, DefaultXMLEncoder, app.XmlEncoder())
+	assert.ErrorIs(T, app.Encodings()[0].Encode(io.Discard, "foo"), ErrContextRequired)
+
+	var decoded string
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("bar"))
+	rw := serveContext(T, app, req, func(c Context) error {
+		// the registered encoding is bound to the context for the binder
+		require.NoError(T, c.Encoding(MIMEApplicationJSON).Decode(c.Request().Body, &decoded))
+		return c.JSON(http.StatusOK, "foo")
+	})
+
+	assert.Equal(T, "BAR", decoded)
+	assert.Equal(T, "FOO", rw.Body.String())
+}
+
+func Test_context_response_encoder_decode(T *testing.T) {
+	app := New(
+		WithResponseEncoder(MIMEApplicationJSON, func(c Context, code int, data interface{}, n *Negotiation) error {
+			return c.String(code, "custom")
+		}),
+		WithResponseEncoder(MIMEApplicationMsgpack, func(c Context, code int, data interface{}, n *Negotiation) error {
+			return c.String(code, "custom")
+		}),
+	)
+	assert.Equal(T, DefaultJSONEncoder, app.JsonEncoder())
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id":2}`))
+	rw := serveContext(T, app, req, func(c Context) error {
+		// request bodies are decoded with the encoding registered before the response encoder
+		var user responseUser
+		require.NoError(T, c.Encoding(MIMEApplicationJSON).Decode(c.Request().Body, &user))
+		assert.Equal(T, 2, user.ID)
+
+		var he *HTTPError
+		require.ErrorAs(T, c.Encoding(MIMEApplicationMsgpack).Decode(c.Request().Body, &user), &he)
+		assert.Equal(T, http.StatusUnsupportedMediaType, he.Code)
+		return c.Negotiate(http.StatusOK, user)
+	})
+
+	assert.Equal(T, "custom", rw.Body.String())
+}
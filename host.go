@@ -0,0 +1,216 @@
+package webapp
+
+import (
+	"sort"
+	"strings"
+)
+
+type hostLabelKind uint8
+
+const (
+	literalLabel hostLabelKind = iota
+	paramLabel
+	wildcardLabel
+)
+
+// hostLabel is a dot separated part of the host pattern
+type hostLabel struct {
+	kind  hostLabelKind
+	value string
+	spec  ParamSpec
+}
+
+// hostRoute is a host pattern with the router handling the requests for the matching hosts
+type hostRoute struct {
+	pattern string
+	router  Router
+
+	// labels of the pattern from right to left, the order in which they are matched
+	labels []hostLabel
+
+	// params are the names of the params in the order they are matched
+	params []string
+}
+
+// newHostRoute parses the host pattern, a label is a literal, a `{name}` param or a `*` wildcard as first label
+// which matches one or more labels.
+func newHostRoute(pattern string, router Router) *hostRoute {
+	if pattern == "" {
+		panic("host pattern must not be empty")
+	}
+
+	h := &hostRoute{
+		pattern: pattern,
+		router:  router,
+		params:  []string{},
+	}
+
+	labels := strings.Split(pattern, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		label := labels[i]
+		switch {
+		case label == "":
+			panic("empty label in host pattern '" + pattern + "'")
+		case label == "*":
+			if i != 0 {
+				panic("wildcard is only allowed as the first label in host pattern '" + pattern + "'")
+			}
+			h.labels = append(h.labels, hostLabel{kind: wildcardLabel})
+		case label[0] == '{':
+			if ParamEnd(label) != len(label)-1 {
+				panic("a param must be the whole label in host pattern '" + pattern + "'")
+			}
+			spec := ParseParamSpec(label[1 : len(label)-1])
+			if spec.Name == "" || spec.Optional {
+				panic("invalid param '" + label + "' in host pattern '" + pattern + "'")
+			}
+			h.labels = append(h.labels, hostLabel{kind: paramLabel, value: spec.Name, spec: spec})
+			h.params = append(h.params, spec.Name)
+		case strings.ContainsAny(label, "{}*"):
+			panic("invalid label '" + label + "' in host pattern '" + pattern + "'")
+		default:
+			h.labels = append(h.labels, hostLabel{kind: literalLabel, value: strings.ToLower(label)})
+		}
+	}
+	return h
+}
+
+// match reports if the host matches the pattern, the values of the params are appended to params
+func (h *hostRoute) match(host string, params *[]string) bool {
+	done := false
+	for _, l := range h.labels {
+		if done {
+			return false
+		}
+		if l.kind == wildcardLabel {
+			return true
+		}
+
+		label := host
+		if i := strings.LastIndexByte(host, '.'); i >= 0 {
+			label, host = host[i+1:], host[:i]
+		} else {
+			done = true
+		}
+
+		switch l.kind {
+		case literalLabel:
+			if label != l.value {
+				return false
+			}
+		case paramLabel:
+			if label == "" || !l.spec.Match(label) {
+				return false
+			}
+			appendParam(params, label)
+		}
+	}
+	return done
+}
+
+// isStatic reports if the pattern only has literal labels
+func (h *hostRoute) isStatic() bool {
+	return len(h.params) == 0 && h.labels[len(h.labels)-1].kind != wildcardLabel
+}
+
+// moreSpecific reports if the pattern should be matched before the other pattern, wildcard patterns are matched last,
+// otherwise the pattern with the most literal labels and then the most constrained params goes first.
+func (h *hostRoute) moreSpecific(other *hostRoute) bool {
+	if w, o := h.hasWildcard(), other.hasWildcard(); w != o {
+		return o
+	}
+	if l, o := h.count(literalLabel), other.count(literalLabel); l != o {
+		return l > o
+	}
+	return h.constraints() > other.constraints()
+}
+
+func (h *hostRoute) hasWildcard() bool {
+	return h.labels[len(h.labels)-1].kind == wildcardLabel
+}
+
+func (h *hostRoute) count(kind hostLabelKind) int {
+	n := 0
+	for _, l := range h.labels {
+		if l.kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+func (h *hostRoute) constraints() int {
+	n := 0
+	for _, l := range h.labels {
+		if l.spec.Constraint != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// hostRouter selects the router for the host of the request
+type hostRouter struct {
+	static  map[string]*hostRoute
+	dynamic []*hostRoute
+}
+
+func (r *hostRouter) empty() bool {
+	return len(r.static) == 0 && len(r.dynamic) == 0
+}
+
+// add returns the router for the host pattern, a new router is created with the factory when the pattern is not
+// registered yet
+func (r *hostRouter) add(pattern string, factory func() Router) Router {
+	route := newHostRoute(pattern, nil)
+	if route.isStatic() {
+		host := strings.ToLower(pattern)
+		if existing, ok := r.static[host]; ok {
+			return existing.router
+		}
+		if r.static == nil {
+			r.static = make(map[string]*hostRoute)
+		}
+		route.router = factory()
+		r.static[host] = route
+		return route.router
+	}
+
+	for _, existing := range r.dynamic {
+		if existing.pattern == route.pattern {
+			return existing.router
+		}
+	}
+	route.router = factory()
+	r.dynamic = append(r.dynamic, route)
+	sort.SliceStable(r.dynamic, func(i, j int) bool {
+		return r.dynamic[i].moreSpecific(r.dynamic[j])
+	})
+	return route.router
+}
+
+// find returns the host route matching the host of the request, the port is ignored
+func (r *hostRouter) find(host string, params *[]string) *hostRoute {
+	host = strings.ToLower(stripPort(host))
+	*params = (*params)[0:0]
+	if route, ok := r.static[host]; ok {
+		return route
+	}
+
+	for _, route := range r.dynamic {
+		*params = (*params)[0:0]
+		if route.match(host, params) {
+			return route
+		}
+	}
+	return nil
+}
+
+// stripPort removes the port from the host, an IPv6 host is enclosed in brackets
+func stripPort(host string) string {
+	i := strings.LastIndexByte(host, ':')
+	if i < 0 || strings.IndexByte(host[i:], ']') >= 0 {
+		return host
+	}
+	return host[:i]
+}
@@ -0,0 +1,123 @@
+package webapp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_webapp_host_routing(T *testing.T) {
+	var callstack []string
+	app := New()
+	app.GET("/", func(c Context) error { return c.String(http.StatusOK, "main") })
+	app.Host("api.example.com").GET("/", func(c Context) error { return c.String(http.StatusOK, "api") })
+	app.Host("{tenant}.example.com", func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			callstack = append(callstack, c.Param("tenant"))
+			return next(c)
+		}
+	}).GET("/users/{id}", func(c Context) error {
+		return c.String(http.StatusOK, "tenant "+c.Param("tenant")+" user "+c.Param("id"))
+	})
+	app.Host("{id:int}.example.com").GET("/users/{id}", func(c Context) error {
+		return c.String(http.StatusOK, "path param "+c.Param("id"))
+	})
+	app.Host("*.example.org").GET("/", func(c Context) error { return c.String(http.StatusOK, "wildcard") })
+
+	tests := map[string]struct {
+		host string
+		path string
+		code int
+		body string
+	}{
+		"literal host":                {host: "api.example.com", path: "/", code: 200, body: "api"},
+		"literal host with port":      {host: "API.example.com:8080", path: "/", code: 200, body: "api"},
+		"param host":                  {host: "acme.example.com", path: "/users/12", code: 200, body: "tenant acme user 12"},
+		"path param takes precedence": {host: "42.example.com", path: "/users/12", code: 200, body: "path param 12"},
+		"param host unknown route":    {host: "acme.example.com", path: "/", code: 404},
+		"literal takes precedence":    {host: "api.example.com", path: "/users/12", code: 404},
+		"wildcard one label":          {host: "www.example.org", path: "/", code: 200, body: "wildcard"},
+		"wildcard more labels":        {host: "a.b.example.org", path: "/", code: 200, body: "wildcard"},
+		"wildcard needs a label":      {host: "example.org", path: "/", code: 200, body: "main"},
+		"param needs one label":       {host: "a.b.example.com", path: "/", code: 200, body: "main"},
+		"unknown host":                {host: "localhost", path: "/", code: 200, body: "main"},
+	}
+
+	for name, test := range tests {
+		T.Run(name, func(T *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, test.path, nil)
+			req.Host = test.host
+			rw := httptest.NewRecorder()
+			app.ServeHTTP(rw, req)
+
+			assert.Equal(T, test.code, rw.Code)
+			if test.code == 200 {
+				assert.Equal(T, test.body, rw.Body.String())
+			}
+		})
+	}
+
+	assert.Equal(T, []string{"acme"}, callstack)
+}
+
+func Test_webapp_host_returns_same_router_for_pattern(T *testing.T) {
+	app := New()
+	app.Host("api.example.com").GET("/users", func(c Context) error { return c.String(http.StatusOK, "get") })
+	app.Host("API.example.com").POST("/users", func(c Context) error { return c.String(http.StatusOK, "post") })
+
+	req, _ := http.NewRequest(http.MethodPost, "/users", nil)
+	req.Host = "api.example.com"
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.Equal(T, "post", rw.Body.String())
+	assert.Len(T, app.Routes().All(), 2)
+}
+
+func Test_webapp_host_app_middleware(T *testing.T) {
+	var callstack []string
+	trace := func(name string) MiddlewareFunc {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(c Context) error {
+				callstack = append(callstack, name)
+				return next(c)
+			}
+		}
+	}
+
+	app := New()
+	app.Use(trace("auth"))
+	api := app.Host("api.example.com", trace("host"))
+	app.Use(trace("after host"))
+	api.GET("/", func(c Context) error { return c.NoContent() })
+	app.GET("/", func(c Context) error { return c.NoContent() })
+
+	for _, host := range []string{"api.example.com", "www.example.com"} {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.Host = host
+		app.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// middleware added after the host group only runs for the routes of the webapp
+	assert.Equal(T, []string{"auth", "host", "auth", "after host"}, callstack)
+}
+
+func Test_webapp_host_invalid_patterns(T *testing.T) {
+	tests := map[string]string{
+		"empty":              "",
+		"empty label":        "api..example.com",
+		"wildcard not first": "api.*.com",
+		"partial param":      "api-{env}.example.com",
+		"optional param":     "{tenant?}.example.com",
+	}
+
+	for name, pattern := range tests {
+		T.Run(name, func(T *testing.T) {
+			assert.Panics(T, func() {
+				New().Host(pattern)
+			})
+		})
+	}
+}
@@ -11,7 +11,9 @@ var (
 	ErrNotFound = NewHTTPError(http.StatusNotFound)
 	//ErrUnauthorized                = NewHTTPError(http.StatusUnauthorized)
 	//ErrForbidden                   = NewHTTPError(http.StatusForbidden)
-	ErrMethodNotAllowed = NewHTTPError(http.StatusMethodNotAllowed)
+	ErrMethodNotAllowed     = NewHTTPError(http.StatusMethodNotAllowed)
+	ErrNotAcceptable        = NewHTTPError(http.StatusNotAcceptable)
+	ErrInvalidJSONPCallback = NewHTTPError(http.StatusBadRequest, "invalid JSONP callback")
 	//ErrStatusRequestEntityTooLarge = NewHTTPError(http.StatusRequestEntityTooLarge)
 	//ErrTooManyRequests             = NewHTTPError(http.StatusTooManyRequests)
 	//ErrBadRequest                  = NewHTTPError(http.StatusBadRequest)
@@ -21,10 +23,16 @@ var (
 	//ErrServiceUnavailable          = NewHTTPError(http.StatusServiceUnavailable)
 	//ErrValidatorNotRegistered = errors.New("validator not registered")
 	//ErrRendererNotRegistered  = errors.New("renderer not registered")
-	ErrInvalidRedirectCode = errors.New("invalid redirect status code")
-	//ErrCookieNotFound         = errors.New("cookie not found")
-	//ErrInvalidCertOrKeyType   = errors.New("invalid cert or key type, must be string or []byte")
-	//ErrInvalidListenerNetwork = errors.New("invalid listener network")
+	ErrInvalidRedirectCode    = errors.New("invalid redirect status code")
+	ErrCookieNotFound         = errors.New("cookie not found")
+	ErrInvalidCertOrKeyType   = errors.New("invalid cert or key type, must be string or []byte")
+	ErrInvalidListenerNetwork = errors.New("invalid listener network")
+	ErrRouteNotFound          = errors.New("route not found")
+	ErrReverseMissingParam    = errors.New("missing route param")
+	ErrReverseExtraParam      = errors.New("too many route params")
+	ErrReverseInvalidParam    = errors.New("route param does not match its constraint")
+	ErrEncoderNotRegistered   = errors.New("encoder not registered")
+	ErrContextRequired        = errors.New("encoder requires a context")
 )
 
 type HTTPError struct {
@@ -0,0 +1,210 @@
+package webapp
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// IPExtractor returns the ip address of the client that sent the request
+type IPExtractor func(r *http.Request) string
+
+// ipTrust decides which proxies are trusted to set the forwarding headers
+type ipTrust struct {
+	loopback   bool
+	linkLocal  bool
+	privateNet bool
+	unixSocket bool
+	ranges     []netip.Prefix
+}
+
+// TrustOption configures the proxies trusted by the ip extractors
+type TrustOption func(*ipTrust)
+
+// TrustLoopback configures if the loopback addresses are trusted, they are trusted by default
+func TrustLoopback(trust bool) TrustOption {
+	return func(t *ipTrust) {
+		t.loopback = trust
+	}
+}
+
+// TrustLinkLocal configures if the link local addresses are trusted, they are trusted by default
+func TrustLinkLocal(trust bool) TrustOption {
+	return func(t *ipTrust) {
+		t.linkLocal = trust
+	}
+}
+
+// TrustPrivateNet configures if the private network addresses (RFC 1918 and RFC 4193) are trusted, they are trusted
+// by default
+func TrustPrivateNet(trust bool) TrustOption {
+	return func(t *ipTrust) {
+		t.privateNet = trust
+	}
+}
+
+// TrustUnixSocket configures if the peers of a unix domain socket listener are trusted, they are trusted by default.
+// The remote address of a unix socket connection has no ip address, a local reverse proxy connecting over the socket
+// must be trusted to use the forwarding headers.
+func TrustUnixSocket(trust bool) TrustOption {
+	return func(t *ipTrust) {
+		t.unixSocket = trust
+	}
+}
+
+// TrustIPRange adds the address range to the trusted proxies, like `netip.MustParsePrefix("203.0.113.0/24")`
+func TrustIPRange(prefix netip.Prefix) TrustOption {
+	return func(t *ipTrust) {
+		t.ranges = append(t.ranges, prefix.Masked())
+	}
+}
+
+func newIPTrust(options ...TrustOption) *ipTrust {
+	t := &ipTrust{
+		loopback:   true,
+		linkLocal:  true,
+		privateNet: true,
+		unixSocket: true,
+	}
+	for _, option := range options {
+		option(t)
+	}
+	return t
+}
+
+func (t *ipTrust) trusts(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if t.loopback && ip.IsLoopback() {
+		return true
+	}
+	if t.linkLocal && (ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast()) {
+		return true
+	}
+	if t.privateNet && ip.IsPrivate() {
+		return true
+	}
+	for _, prefix := range t.ranges {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtractIPDirect uses the remote address of the connection as client ip, to be used when the webapp is exposed to the
+// clients without a proxy in between. This is the default ip extractor.
+func ExtractIPDirect() IPExtractor {
+	return directIP
+}
+
+func directIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ExtractIPFromRealIPHeader uses the ip address of the `X-Real-Ip` header when the request is sent by a trusted proxy,
+// otherwise the remote address of the connection is used.
+func ExtractIPFromRealIPHeader(options ...TrustOption) IPExtractor {
+	trust := newIPTrust(options...)
+	return func(r *http.Request) string {
+		direct := directIP(r)
+		if !trustedPeer(trust, r) {
+			return direct
+		}
+		if ip, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get(HeaderXRealIP))); err == nil {
+			return ip.Unmap().String()
+		}
+		return direct
+	}
+}
+
+// ExtractIPFromXFFHeader uses the `X-Forwarded-For` header when the request is sent by a trusted proxy. The addresses
+// are checked from right to left, the first address that is not a trusted proxy is the client ip. Addresses added by
+// the client before the first untrusted address are ignored, so they cannot be spoofed.
+func ExtractIPFromXFFHeader(options ...TrustOption) IPExtractor {
+	trust := newIPTrust(options...)
+	return func(r *http.Request) string {
+		var hops []string
+		for _, value := range r.Header.Values(HeaderXForwardedFor) {
+			for _, hop := range strings.Split(value, ",") {
+				hops = append(hops, strings.TrimSpace(hop))
+			}
+		}
+		return extractIPFromHops(r, trust, hops)
+	}
+}
+
+// ExtractIPFromForwardedHeader uses the `for` parameters of the RFC 7239 `Forwarded` header when the request is sent
+// by a trusted proxy. The addresses are checked from right to left like ExtractIPFromXFFHeader, an obfuscated or
+// unknown identifier stops the search and the remote address of the connection is used.
+func ExtractIPFromForwardedHeader(options ...TrustOption) IPExtractor {
+	trust := newIPTrust(options...)
+	return func(r *http.Request) string {
+		var hops []string
+		for _, value := range r.Header.Values(HeaderForwarded) {
+			for _, element := range strings.Split(value, ",") {
+				hops = append(hops, forwardedFor(element))
+			}
+		}
+		return extractIPFromHops(r, trust, hops)
+	}
+}
+
+// forwardedFor returns the node of the `for` parameter of the forwarded element, the port is removed
+func forwardedFor(element string) string {
+	for _, pair := range strings.Split(element, ";") {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+		if !strings.EqualFold(key, "for") {
+			continue
+		}
+
+		node := strings.Trim(strings.TrimSpace(value), `"`)
+		if strings.HasPrefix(node, "[") {
+			// ipv6 is enclosed in brackets, optionally followed by the port
+			if end := strings.IndexByte(node, ']'); end > 0 {
+				return node[1:end]
+			}
+			return node
+		}
+		if host, _, err := net.SplitHostPort(node); err == nil {
+			return host
+		}
+		return node
+	}
+	return ""
+}
+
+// extractIPFromHops returns the rightmost address of the proxy chain that is not trusted, the remote address is used
+// when the request is not sent by a trusted proxy or an address in the chain is invalid
+func extractIPFromHops(r *http.Request, trust *ipTrust, hops []string) string {
+	direct := directIP(r)
+	if !trustedPeer(trust, r) {
+		return direct
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip, err := netip.ParseAddr(hops[i])
+		if err != nil {
+			return direct
+		}
+		ip = ip.Unmap()
+		if !trust.trusts(ip) || i == 0 {
+			return ip.String()
+		}
+	}
+	return direct
+}
+
+// trustedPeer reports if the request is sent by a trusted proxy, a request received on a unix socket listener is
+// trusted when the unix socket peers are trusted
+func trustedPeer(trust *ipTrust, r *http.Request) bool {
+	if addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr); ok && addr.Network() == "unix" {
+		return trust.unixSocket
+	}
+	ip, err := netip.ParseAddr(directIP(r))
+	return err == nil && trust.trusts(ip)
+}
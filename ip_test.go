@@ -0,0 +1,117 @@
+package webapp
+
+import (
+	stdContext "context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ip_extractors(T *testing.T) {
+	lb := TrustIPRange(netip.MustParsePrefix("203.0.113.0/24"))
+
+	tests := map[string]struct {
+		extractor  IPExtractor
+		remoteAddr string
+		unix       bool
+		header     http.Header
+		expect     string
+	}{
+		// direct
+		"direct":                   {extractor: ExtractIPDirect(), remoteAddr: "198.51.100.7:5123", expect: "198.51.100.7"},
+		"direct ipv6":              {extractor: ExtractIPDirect(), remoteAddr: "[2001:db8::1]:5123", expect: "2001:db8::1"},
+		"direct without port":      {extractor: ExtractIPDirect(), remoteAddr: "198.51.100.7", expect: "198.51.100.7"},
+		"direct ignores xff":       {extractor: ExtractIPDirect(), remoteAddr: "10.0.0.1:80", header: http.Header{"X-Forwarded-For": {"1.1.1.1"}}, expect: "10.0.0.1"},
+		"direct ignores x-real-ip": {extractor: ExtractIPDirect(), remoteAddr: "10.0.0.1:80", header: http.Header{"X-Real-Ip": {"1.1.1.1"}}, expect: "10.0.0.1"},
+		"direct ignores forwarded": {extractor: ExtractIPDirect(), remoteAddr: "10.0.0.1:80", header: http.Header{"Forwarded": {"for=1.1.1.1"}}, expect: "10.0.0.1"},
+
+		// x-real-ip
+		"real ip from trusted proxy":      {extractor: ExtractIPFromRealIPHeader(), remoteAddr: "10.0.0.1:80", header: http.Header{"X-Real-Ip": {"198.51.100.7"}}, expect: "198.51.100.7"},
+		"real ip spoofed by client":       {extractor: ExtractIPFromRealIPHeader(), remoteAddr: "198.51.100.7:80", header: http.Header{"X-Real-Ip": {"10.0.0.5"}}, expect: "198.51.100.7"},
+		"real ip invalid":                 {extractor: ExtractIPFromRealIPHeader(), remoteAddr: "10.0.0.1:80", header: http.Header{"X-Real-Ip": {"<script>"}}, expect: "10.0.0.1"},
+		"real ip missing":                 {extractor: ExtractIPFromRealIPHeader(), remoteAddr: "10.0.0.1:80", expect: "10.0.0.1"},
+		"real ip private not trusted":     {extractor: ExtractIPFromRealIPHeader(TrustPrivateNet(false)), remoteAddr: "10.0.0.1:80", header: http.Header{"X-Real-Ip": {"198.51.100.7"}}, expect: "10.0.0.1"},
+		"real ip trusted range":           {extractor: ExtractIPFromRealIPHeader(lb), remoteAddr: "203.0.113.10:80", header: http.Header{"X-Real-Ip": {"198.51.100.7"}}, expect: "198.51.100.7"},
+		"real ip ipv4 mapped":             {extractor: ExtractIPFromRealIPHeader(), remoteAddr: "127.0.0.1:80", header: http.Header{"X-Real-Ip": {"::ffff:198.51.100.7"}}, expect: "198.51.100.7"},
+		"real ip loopback not trusted":    {extractor: ExtractIPFromRealIPHeader(TrustLoopback(false)), remoteAddr: "127.0.0.1:80", header: http.Header{"X-Real-Ip": {"198.51.100.7"}}, expect: "127.0.0.1"},
+		"real ip link local not trusted":  {extractor: ExtractIPFromRealIPHeader(TrustLinkLocal(false)), remoteAddr: "[fe80::1]:80", header: http.Header{"X-Real-Ip": {"198.51.100.7"}}, expect: "fe80::1"},
+		"real ip link local trusted ipv6": {extractor: ExtractIPFromRealIPHeader(), remoteAddr: "[fe80::1]:80", header: http.Header{"X-Real-Ip": {"2001:db8::7"}}, expect: "2001:db8::7"},
+
+		// x-forwarded-for
+		"xff single proxy":                {extractor: ExtractIPFromXFFHeader(), remoteAddr: "10.0.0.1:80", header: http.Header{"X-Forwarded-For": {"198.51.100.7"}}, expect: "198.51.100.7"},
+		"xff chain of trusted proxies":    {extractor: ExtractIPFromXFFHeader(lb), remoteAddr: "10.0.0.1:80", header: http.Header{"X-Forwarded-For": {"198.51.100.7, 203.0.113.10, 10.0.0.2"}}, expect: "198.51.100.7"},
+		"xff spoofed by direct client":    {extractor: ExtractIPFromXFFHeader(), remoteAddr: "198.51.100.7:80", header: http.Header{"X-Forwarded-For": {"10.0.0.5"}}, expect: "198.51.100.7"},
+		"xff spoofed entry before client": {extractor: ExtractIPFromXFFHeader(), remoteAddr: "10.0.0.1:80", header: http.Header{"X-Forwarded-For": {"1.1.1.1, 198.51.100.7"}}, expect: "198.51.100.7"},
+		"xff spoofed trusted address":     {extractor: ExtractIPFromXFFHeader(), remoteAddr: "10.0.0.1:80", header: http.Header{"X-Forwarded-For": {"10.0.0.9, 198.51.100.7"}}, expect: "198.51.100.7"},
+		"xff untrusted proxy in chain":    {extractor: ExtractIPFromXFFHeader(), remoteAddr: "10.0.0.1:80", header: http.Header{"X-Forwarded-For": {"198.51.100.7, 203.0.113.10"}}, expect: "203.0.113.10"},
+		"xff multiple headers":            {extractor: ExtractIPFromXFFHeader(lb), remoteAddr: "10.0.0.1:80", header: http.Header{"X-Forwarded-For": {"198.51.100.7", "203.0.113.10"}}, expect: "198.51.100.7"},
+		"xff all trusted":                 {extractor: ExtractIPFromXFFHeader(), remoteAddr: "10.0.0.1:80", header: http.Header{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}}, expect: "10.0.0.3"},
+		"xff invalid entry":               {extractor: ExtractIPFromXFFHeader(), remoteAddr: "10.0.0.1:80", header: http.Header{"X-Forwarded-For": {"198.51.100.7, garbage"}}, expect: "10.0.0.1"},
+		"xff empty entry":                 {extractor: ExtractIPFromXFFHeader(), remoteAddr: "10.0.0.1:80", header: http.Header{"X-Forwarded-For": {"198.51.100.7,"}}, expect: "10.0.0.1"},
+		"xff missing":                     {extractor: ExtractIPFromXFFHeader(), remoteAddr: "10.0.0.1:80", expect: "10.0.0.1"},
+		"xff ipv6":                        {extractor: ExtractIPFromXFFHeader(), remoteAddr: "[fd00::1]:80", header: http.Header{"X-Forwarded-For": {"2001:db8::7"}}, expect: "2001:db8::7"},
+		"xff private not trusted":         {extractor: ExtractIPFromXFFHeader(TrustPrivateNet(false), lb), remoteAddr: "203.0.113.10:80", header: http.Header{"X-Forwarded-For": {"198.51.100.7, 10.0.0.2"}}, expect: "10.0.0.2"},
+
+		// forwarded
+		"forwarded":                       {extractor: ExtractIPFromForwardedHeader(), remoteAddr: "10.0.0.1:80", header: http.Header{"Forwarded": {"for=198.51.100.7;proto=https;by=10.0.0.1"}}, expect: "198.51.100.7"},
+		"forwarded ipv4 with port":        {extractor: ExtractIPFromForwardedHeader(), remoteAddr: "10.0.0.1:80", header: http.Header{"Forwarded": {`for="198.51.100.7:4711"`}}, expect: "198.51.100.7"},
+		"forwarded ipv6":                  {extractor: ExtractIPFromForwardedHeader(), remoteAddr: "10.0.0.1:80", header: http.Header{"Forwarded": {`For="[2001:db8:cafe::17]:4711"`}}, expect: "2001:db8:cafe::17"},
+		"forwarded chain":                 {extractor: ExtractIPFromForwardedHeader(lb), remoteAddr: "10.0.0.1:80", header: http.Header{"Forwarded": {"for=198.51.100.7, for=203.0.113.10;proto=https"}}, expect: "198.51.100.7"},
+		"forwarded multiple headers":      {extractor: ExtractIPFromForwardedHeader(lb), remoteAddr: "10.0.0.1:80", header: http.Header{"Forwarded": {"for=198.51.100.7", "for=203.0.113.10"}}, expect: "198.51.100.7"},
+		"forwarded spoofed by client":     {extractor: ExtractIPFromForwardedHeader(), remoteAddr: "198.51.100.7:80", header: http.Header{"Forwarded": {"for=10.0.0.5"}}, expect: "198.51.100.7"},
+		"forwarded spoofed before client": {extractor: ExtractIPFromForwardedHeader(), remoteAddr: "10.0.0.1:80", header: http.Header{"Forwarded": {"for=1.1.1.1, for=198.51.100.7"}}, expect: "198.51.100.7"},
+		"forwarded obfuscated":            {extractor: ExtractIPFromForwardedHeader(), remoteAddr: "10.0.0.1:80", header: http.Header{"Forwarded": {"for=_hidden"}}, expect: "10.0.0.1"},
+		"forwarded unknown":               {extractor: ExtractIPFromForwardedHeader(), remoteAddr: "10.0.0.1:80", header: http.Header{"Forwarded": {"for=unknown"}}, expect: "10.0.0.1"},
+		"forwarded without for":           {extractor: ExtractIPFromForwardedHeader(), remoteAddr: "10.0.0.1:80", header: http.Header{"Forwarded": {"proto=https"}}, expect: "10.0.0.1"},
+		"forwarded ignores xff":           {extractor: ExtractIPFromForwardedHeader(), remoteAddr: "10.0.0.1:80", header: http.Header{"X-Forwarded-For": {"1.1.1.1"}}, expect: "10.0.0.1"},
+
+		// unix socket
+		"unix socket direct":                {extractor: ExtractIPDirect(), remoteAddr: "@", unix: true, expect: "@"},
+		"unix socket real ip":               {extractor: ExtractIPFromRealIPHeader(), remoteAddr: "@", unix: true, header: http.Header{"X-Real-Ip": {"198.51.100.7"}}, expect: "198.51.100.7"},
+		"unix socket xff":                   {extractor: ExtractIPFromXFFHeader(), remoteAddr: "", unix: true, header: http.Header{"X-Forwarded-For": {"198.51.100.7"}}, expect: "198.51.100.7"},
+		"unix socket forwarded":             {extractor: ExtractIPFromForwardedHeader(), remoteAddr: "@", unix: true, header: http.Header{"Forwarded": {"for=198.51.100.7"}}, expect: "198.51.100.7"},
+		"unix socket not trusted":           {extractor: ExtractIPFromXFFHeader(TrustUnixSocket(false)), remoteAddr: "@", unix: true, header: http.Header{"X-Forwarded-For": {"198.51.100.7"}}, expect: "@"},
+		"unix socket real ip not trusted":   {extractor: ExtractIPFromRealIPHeader(TrustUnixSocket(false)), remoteAddr: "@", unix: true, header: http.Header{"X-Real-Ip": {"198.51.100.7"}}, expect: "@"},
+		"unix socket xff untrusted proxy":   {extractor: ExtractIPFromXFFHeader(), remoteAddr: "@", unix: true, header: http.Header{"X-Forwarded-For": {"198.51.100.7, 203.0.113.10"}}, expect: "203.0.113.10"},
+		"tcp without remote ip not trusted": {extractor: ExtractIPFromXFFHeader(), remoteAddr: "@", header: http.Header{"X-Forwarded-For": {"198.51.100.7"}}, expect: "@"},
+	}
+
+	for name, test := range tests {
+		T.Run(name, func(T *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = test.remoteAddr
+			if test.unix {
+				local := &net.UnixAddr{Name: "/run/app.sock", Net: "unix"}
+				req = req.WithContext(stdContext.WithValue(req.Context(), http.LocalAddrContextKey, local))
+			}
+			for k, v := range test.header {
+				req.Header[k] = v
+			}
+
+			assert.Equal(T, test.expect, test.extractor(req))
+		})
+	}
+}
+
+func Test_context_real_ip(T *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:5123"
+	req.Header.Set(HeaderXForwardedFor, "198.51.100.7")
+
+	var direct, forwarded string
+	serveContext(T, New(), req, func(c Context) error {
+		direct = c.RealIP()
+		return nil
+	})
+	serveContext(T, New(WithIPExtractor(ExtractIPFromXFFHeader())), req, func(c Context) error {
+		forwarded = c.RealIP()
+		return nil
+	})
+
+	assert.Equal(T, "10.0.0.1", direct)
+	assert.Equal(T, "198.51.100.7", forwarded)
+}
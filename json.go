@@ -3,31 +3,57 @@ package webapp
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"regexp"
 )
 
+// jsonpPrefix is written before the callback, the comment prevents the response from being interpreted as another
+// content type like a flash file
+const jsonpPrefix = "/**/"
+
+var jsonpCallbackPattern = regexp.MustCompile(`^[a-zA-Z_$][a-zA-Z0-9_$]*(\.[a-zA-Z_$][a-zA-Z0-9_$]*)*$`)
+
+// validJSONPCallback reports if the callback is a javascript identifier or a dot separated path of identifiers, so a
+// callback taken from the request cannot inject a script
+func validJSONPCallback(callback string) bool {
+	return len(callback) <= 128 && jsonpCallbackPattern.MatchString(callback)
+}
+
+// DefaultJSONEncoding implements the json Encoding using encoding/json, registered by default.
+var DefaultJSONEncoding IndentEncoding
+
 // DefaultJSONEncoder implements JSON encoding using encoding/json.
 var DefaultJSONEncoder JSONEncoding
 
 func init() {
-	DefaultJSONEncoder = defaultJsonEncoder{}
+	DefaultJSONEncoding = jsonEncoding{}
+	DefaultJSONEncoder = encodingEncoder{encoding: DefaultJSONEncoding}
 }
 
-type defaultJsonEncoder struct{}
+type jsonEncoding struct{}
+
+func (jsonEncoding) MediaType() string {
+	return MIMEApplicationJSON
+}
+
+// Encode converts an interface into a json and writes it to the writer.
+func (e jsonEncoding) Encode(w io.Writer, i interface{}) error {
+	return e.EncodeIndent(w, i, "")
+}
 
-// Encode converts an interface into a json and writes it to the response.
-// You can optionally use the indent parameter to produce pretty JSONs.
-func (defaultJsonEncoder) Encode(c Context, i interface{}, indent string) error {
-	enc := json.NewEncoder(c.Response())
+// EncodeIndent converts an interface into a pretty printed json and writes it to the writer.
+func (jsonEncoding) EncodeIndent(w io.Writer, i interface{}, indent string) error {
+	enc := json.NewEncoder(w)
 	if indent != "" {
 		enc.SetIndent("", indent)
 	}
 	return enc.Encode(i)
 }
 
-// Decode reads a JSON from a request body and converts it into an interface.
-func (defaultJsonEncoder) Decode(c Context, i interface{}) error {
-	err := json.NewDecoder(c.Request().Body).Decode(i)
+// Decode reads a JSON from the reader and converts it into an interface.
+func (jsonEncoding) Decode(r io.Reader, i interface{}) error {
+	err := json.NewDecoder(r).Decode(i)
 	if ute, ok := err.(*json.UnmarshalTypeError); ok {
 		return NewHTTPErrorWithInternal(http.StatusBadRequest, err, fmt.Sprintf("Unmarshal type error: expected=%v, got=%v, field=%v, offset=%v", ute.Type, ute.Value, ute.Field, ute.Offset))
 	} else if se, ok := err.(*json.SyntaxError); ok {
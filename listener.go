@@ -0,0 +1,68 @@
+package webapp
+
+import (
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// listenFdsStart is the first file descriptor passed by systemd socket activation
+const listenFdsStart = 3
+
+// listen creates the listener for the address. An address without a scheme is a tcp address.
+func listen(address string) (net.Listener, error) {
+	scheme, addr, found := strings.Cut(address, "://")
+	if !found {
+		return net.Listen("tcp", address)
+	}
+
+	switch scheme {
+	case "tcp", "tcp4", "tcp6", "unix":
+		return net.Listen(scheme, addr)
+	case "fd":
+		return fileListener(addr)
+	}
+	return nil, ErrInvalidListenerNetwork
+}
+
+// fileListener creates a listener from an inherited file descriptor, referenced by number or by the name
+// systemd passed in LISTEN_FDNAMES.
+func fileListener(fd string) (net.Listener, error) {
+	n, err := strconv.Atoi(fd)
+	if err != nil {
+		if n, err = systemdListenFd(fd); err != nil {
+			return nil, err
+		}
+	}
+
+	f := os.NewFile(uintptr(n), fd)
+	if f == nil {
+		return nil, errors.New("invalid file descriptor " + fd)
+	}
+	defer f.Close()
+
+	// FileListener duplicates the file descriptor, so the file can be closed
+	return net.FileListener(f)
+}
+
+// systemdListenFd finds the file descriptor for the named socket passed by systemd socket activation
+func systemdListenFd(name string) (int, error) {
+	if pid := os.Getenv("LISTEN_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, errors.New("socket activation file descriptors are not passed to this process")
+	}
+
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil {
+		return 0, errors.New("no socket activation file descriptors found for name " + name)
+	}
+
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	for i := 0; i < count && i < len(names); i++ {
+		if names[i] == name {
+			return listenFdsStart + i, nil
+		}
+	}
+	return 0, errors.New("no socket activation file descriptor found for name " + name)
+}
@@ -0,0 +1,120 @@
+package webapp
+
+import (
+	stdContext "context"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func waitForListener(T *testing.T, app WebApp) {
+	require.Eventually(T, func() bool {
+		return app.ListenerAddr() != nil
+	}, time.Second, time.Millisecond, "server did not start")
+}
+
+func Test_webapp_start_unix_socket(T *testing.T) {
+	socket := filepath.Join(T.TempDir(), "app.sock")
+
+	app := New()
+	started := make(chan struct{})
+	app.Pre(slowHandler(100*time.Millisecond, started))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- app.Start("unix://" + socket)
+	}()
+	waitForListener(T, app)
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx stdContext.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+
+	inFlight := make(chan string, 1)
+	go func() {
+		res, err := client.Get("http://unix/")
+		if err != nil {
+			inFlight <- err.Error()
+			return
+		}
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		inFlight <- string(body)
+	}()
+	<-started
+
+	require.NoError(T, app.Shutdown(stdContext.Background()))
+	assert.Equal(T, "done", <-inFlight)
+	assert.ErrorIs(T, <-done, http.ErrServerClosed)
+
+	// the socket file is removed when the listener is closed
+	_, err := net.Dial("unix", socket)
+	assert.Error(T, err)
+}
+
+func Test_webapp_start_file_descriptor(T *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(T, err)
+	defer l.Close()
+
+	f, err := l.(*net.TCPListener).File()
+	require.NoError(T, err)
+	defer f.Close()
+
+	app := New()
+	app.Pre(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			return c.String(http.StatusOK, "fd")
+		}
+	})
+
+	go app.Start("fd://" + strconv.Itoa(int(f.Fd())))
+	waitForListener(T, app)
+	defer app.Close()
+
+	res, err := http.Get("http://" + l.Addr().String() + "/")
+	require.NoError(T, err)
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+
+	assert.Equal(T, "fd", string(body))
+}
+
+func Test_webapp_start_listener(T *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(T, err)
+
+	app := New()
+	done := make(chan error, 1)
+	go func() {
+		done <- app.StartListener(l)
+	}()
+	waitForListener(T, app)
+
+	assert.Equal(T, l.Addr(), app.ListenerAddr())
+
+	require.NoError(T, app.Shutdown(stdContext.Background()))
+	assert.ErrorIs(T, <-done, http.ErrServerClosed)
+}
+
+func Test_webapp_start_systemd_named_socket_not_found(T *testing.T) {
+	T.Setenv("LISTEN_FDS", "1")
+	T.Setenv("LISTEN_FDNAMES", "http")
+
+	assert.Error(T, New().Start("fd://admin"))
+}
+
+func Test_webapp_start_invalid_listener_network(T *testing.T) {
+	assert.ErrorIs(T, New().Start("udp://127.0.0.1:0"), ErrInvalidListenerNetwork)
+}
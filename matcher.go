@@ -0,0 +1,151 @@
+package webapp
+
+import (
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RouteMatcher is a condition on the request a route requires on top of the method and path. Routes with the same
+// method and path but different matchers can be registered with RouteGroup.With, the route with the most matchers
+// that all match the request is used.
+type RouteMatcher interface {
+	// Match reports if the request satisfies the condition
+	Match(r *http.Request) bool
+
+	// Vary returns the request header the condition depends on, empty when it does not depend on a header.
+	// The header is added to the Vary response header.
+	Vary() string
+}
+
+// MatchAll reports if the request satisfies all the matchers
+func MatchAll(matchers []RouteMatcher, r *http.Request) bool {
+	for _, m := range matchers {
+		if !m.Match(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// SameMatchers reports if both lists hold equal matchers in any order, a route registered with the same matchers as
+// an existing route for the method and path can never be selected
+func SameMatchers(a, b []RouteMatcher) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	used := make([]bool, len(b))
+	for _, m := range a {
+		found := false
+		for i, other := range b {
+			if !used[i] && reflect.DeepEqual(m, other) {
+				used[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// VaryHeaders returns the sorted and comma separated list of the headers the matchers depend on
+func VaryHeaders(matchers []RouteMatcher) string {
+	var headers []string
+	for _, m := range matchers {
+		if h := m.Vary(); h != "" && !contains(headers, h) {
+			headers = append(headers, h)
+		}
+	}
+	sort.Strings(headers)
+	return strings.Join(headers, ", ")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+type headerMatcher struct {
+	name  string
+	value string
+}
+
+// MatchHeader matches the requests with the header value, an empty value matches when the header is present
+func MatchHeader(name, value string) RouteMatcher {
+	return &headerMatcher{name: http.CanonicalHeaderKey(name), value: value}
+}
+
+func (m *headerMatcher) Match(r *http.Request) bool {
+	values, ok := r.Header[m.name]
+	if !ok {
+		return false
+	}
+	return m.value == "" || contains(values, m.value)
+}
+
+func (m *headerMatcher) Vary() string {
+	return m.name
+}
+
+type queryMatcher struct {
+	name  string
+	value string
+}
+
+// MatchQuery matches the requests with the query param value, an empty value matches when the param is present
+func MatchQuery(name, value string) RouteMatcher {
+	return &queryMatcher{name: name, value: value}
+}
+
+func (m *queryMatcher) Match(r *http.Request) bool {
+	values, ok := r.URL.Query()[m.name]
+	if !ok {
+		return false
+	}
+	return m.value == "" || contains(values, m.value)
+}
+
+func (m *queryMatcher) Vary() string {
+	return ""
+}
+
+type mediaTypeMatcher struct {
+	mediaType string
+}
+
+// MatchMediaType matches the requests that accept the media type, `application/vnd.acme.v2+json`. The media type must
+// be listed in the Accept header, wildcards like `*/*` do not match so a route without the matcher handles them.
+func MatchMediaType(mediaType string) RouteMatcher {
+	return &mediaTypeMatcher{mediaType: strings.ToLower(mediaType)}
+}
+
+func (m *mediaTypeMatcher) Match(r *http.Request) bool {
+	for _, header := range r.Header[HeaderAccept] {
+		for _, accept := range strings.Split(header, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accept))
+			if err != nil || mediaType != m.mediaType {
+				continue
+			}
+			if q, ok := params["q"]; ok {
+				if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+func (m *mediaTypeMatcher) Vary() string {
+	return HeaderAccept
+}
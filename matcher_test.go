@@ -0,0 +1,105 @@
+package webapp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_route_matchers(T *testing.T) {
+	tests := map[string]struct {
+		matcher RouteMatcher
+		target  string
+		header  http.Header
+		match   bool
+	}{
+		"header value":             {matcher: MatchHeader("x-api-version", "2"), header: http.Header{"X-Api-Version": {"2"}}, match: true},
+		"header other value":       {matcher: MatchHeader("X-Api-Version", "2"), header: http.Header{"X-Api-Version": {"1"}}},
+		"header present":           {matcher: MatchHeader("X-Api-Version", ""), header: http.Header{"X-Api-Version": {"1"}}, match: true},
+		"header missing":           {matcher: MatchHeader("X-Api-Version", "")},
+		"query value":              {matcher: MatchQuery("version", "2"), target: "/?version=2", match: true},
+		"query present":            {matcher: MatchQuery("debug", ""), target: "/?debug", match: true},
+		"query missing":            {matcher: MatchQuery("debug", ""), target: "/?version=2"},
+		"media type":               {matcher: MatchMediaType("application/vnd.acme.v2+json"), header: http.Header{"Accept": {"text/html, application/vnd.acme.v2+json; q=0.9"}}, match: true},
+		"media type case":          {matcher: MatchMediaType("application/vnd.acme.V2+json"), header: http.Header{"Accept": {"application/vnd.acme.v2+JSON"}}, match: true},
+		"media type not accepted":  {matcher: MatchMediaType("application/vnd.acme.v2+json"), header: http.Header{"Accept": {"application/vnd.acme.v2+json;q=0"}}},
+		"media type no wildcard":   {matcher: MatchMediaType("application/vnd.acme.v2+json"), header: http.Header{"Accept": {"*/*"}}},
+		"media type other version": {matcher: MatchMediaType("application/vnd.acme.v2+json"), header: http.Header{"Accept": {"application/vnd.acme.v1+json"}}},
+	}
+
+	for name, test := range tests {
+		T.Run(name, func(T *testing.T) {
+			target := test.target
+			if target == "" {
+				target = "/"
+			}
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			for k, v := range test.header {
+				req.Header[k] = v
+			}
+
+			assert.Equal(T, test.match, test.matcher.Match(req))
+		})
+	}
+}
+
+func Test_default_router_route_matchers(T *testing.T) {
+	reply := func(body string) HandlerFunc {
+		return func(c Context) error { return c.String(http.StatusOK, body) }
+	}
+
+	app := New()
+	app.GET("/users/{id}", reply("default"))
+	app.With(MatchMediaType("application/vnd.acme.v2+json")).GET("/users/{id}", reply("v2"))
+	app.With(MatchHeader("X-Api-Version", "2")).GET("/users/{id}", reply("v2 header"))
+	app.With(MatchHeader("X-Api-Version", "2"), MatchQuery("debug", "")).GET("/users/{id}", reply("v2 debug"))
+	app.With(MatchHeader("X-Api-Version", "3")).GET("/status", reply("v3 status"))
+
+	tests := map[string]struct {
+		target string
+		header http.Header
+		code   int
+		body   string
+	}{
+		"no matchers":   {target: "/users/1", code: 200, body: "default"},
+		"media type":    {target: "/users/1", header: http.Header{"Accept": {"application/vnd.acme.v2+json"}}, code: 200, body: "v2"},
+		"header":        {target: "/users/1", header: http.Header{"X-Api-Version": {"2"}}, code: 200, body: "v2 header"},
+		"most specific": {target: "/users/1?debug", header: http.Header{"X-Api-Version": {"2"}}, code: 200, body: "v2 debug"},
+		"unknown value": {target: "/users/1", header: http.Header{"X-Api-Version": {"9"}}, code: 200, body: "default"},
+		"only matcher":  {target: "/status", header: http.Header{"X-Api-Version": {"3"}}, code: 200, body: "v3 status"},
+		"no match":      {target: "/status", code: 404},
+	}
+
+	for name, test := range tests {
+		T.Run(name, func(T *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, test.target, nil)
+			for k, v := range test.header {
+				req.Header[k] = v
+			}
+			rw := httptest.NewRecorder()
+			app.ServeHTTP(rw, req)
+
+			assert.Equal(T, test.code, rw.Code)
+			if test.code == 200 {
+				assert.Equal(T, test.body, rw.Body.String())
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+	assert.Equal(T, "Accept, X-Api-Version", rw.Header().Get(HeaderVary))
+
+	assert.Panics(T, func() {
+		app.GET("/users/{id}", reply("duplicate"))
+	})
+	assert.Panics(T, func() {
+		app.With(MatchQuery("debug", ""), MatchHeader("X-Api-Version", "2")).GET("/users/{id}", reply("duplicate"))
+	})
+	assert.NotPanics(T, func() {
+		app.With(MatchHeader("X-Api-Version", "3")).GET("/users/{id}", reply("v3 header"))
+	})
+}
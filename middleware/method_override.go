@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"github.com/mbict/webapp"
+	"net/http"
+	"strings"
+)
+
+// MethodGetter returns the method to override the request method with, an empty string when the request has no override
+type MethodGetter func(c webapp.Context) string
+
+// MethodFromHeader takes the method from the request header, like `X-HTTP-Method-Override`
+func MethodFromHeader(header string) MethodGetter {
+	return func(c webapp.Context) string {
+		return c.Request().Header.Get(header)
+	}
+}
+
+// MethodFromForm takes the method from the form field, like `_method`. The body of the request is parsed as form with
+// the multipart memory limit of the webapp, the values remain available with the form methods of the context.
+func MethodFromForm(field string) MethodGetter {
+	return func(c webapp.Context) string {
+		return c.FormValue(field)
+	}
+}
+
+// MethodFromQuery takes the method from the query param, like `_method`
+func MethodFromQuery(param string) MethodGetter {
+	return func(c webapp.Context) string {
+		return c.QueryParam(param)
+	}
+}
+
+type methodOverride struct {
+	getters []MethodGetter
+	allowed []string
+}
+
+// MethodOverrideOption configures the method override middleware
+type MethodOverrideOption func(*methodOverride)
+
+// WithMethodGetter sets the sources of the method, the first source that returns a method is used.
+// By default the method is taken from the `X-HTTP-Method-Override` header.
+func WithMethodGetter(getters ...MethodGetter) MethodOverrideOption {
+	return func(m *methodOverride) {
+		m.getters = getters
+	}
+}
+
+// WithAllowedMethods sets the methods a request can be overridden to, by default PUT, PATCH and DELETE are allowed.
+func WithAllowedMethods(methods ...string) MethodOverrideOption {
+	return func(m *methodOverride) {
+		m.allowed = make([]string, len(methods))
+		for i, method := range methods {
+			m.allowed[i] = strings.ToUpper(method)
+		}
+	}
+}
+
+// MethodOverride returns the middleware that overrides the method of POST requests, for clients like html forms that
+// can only send GET and POST requests. Methods that are not allowed are ignored and the request stays a POST request.
+//
+// The middleware must be registered with Pre, it has to run before the request is routed so the route of the
+// overridden method is used.
+//
+// Example: `app.Pre(middleware.MethodOverride(middleware.WithMethodGetter(middleware.MethodFromForm("_method"))))`
+func MethodOverride(options ...MethodOverrideOption) webapp.MiddlewareFunc {
+	m := &methodOverride{
+		getters: []MethodGetter{MethodFromHeader(webapp.HeaderXHTTPMethodOverride)},
+		allowed: []string{http.MethodPut, http.MethodPatch, http.MethodDelete},
+	}
+	for _, option := range options {
+		option(m)
+	}
+
+	return func(next webapp.HandlerFunc) webapp.HandlerFunc {
+		return func(c webapp.Context) error {
+			if c.Request().Method == http.MethodPost {
+				if method := m.method(c); method != "" {
+					c.Request().Method = method
+				}
+			}
+			return next(c)
+		}
+	}
+}
+
+// method returns the allowed method of the first getter that returns a method
+func (m *methodOverride) method(c webapp.Context) string {
+	for _, getter := range m.getters {
+		method := strings.ToUpper(strings.TrimSpace(getter(c)))
+		if method == "" {
+			continue
+		}
+		for _, allowed := range m.allowed {
+			if method == allowed {
+				return method
+			}
+		}
+		return ""
+	}
+	return ""
+}
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"bytes"
+	"github.com/mbict/webapp"
+	"github.com/stretchr/testify/assert"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_method_override(t *testing.T) {
+	tests := map[string]struct {
+		options []MethodOverrideOption
+		method  string
+		target  string
+		header  http.Header
+		body    string
+		expect  string
+	}{
+		"header":               {method: http.MethodPost, header: http.Header{"X-Http-Method-Override": {"delete"}}, expect: http.MethodDelete},
+		"no override":          {method: http.MethodPost, expect: http.MethodPost},
+		"only post":            {method: http.MethodGet, header: http.Header{"X-Http-Method-Override": {"DELETE"}}, expect: http.MethodGet},
+		"not allowed":          {method: http.MethodPost, header: http.Header{"X-Http-Method-Override": {"CONNECT"}}, expect: http.MethodPost},
+		"allowed methods":      {options: []MethodOverrideOption{WithAllowedMethods("put")}, method: http.MethodPost, header: http.Header{"X-Http-Method-Override": {"DELETE"}}, expect: http.MethodPost},
+		"form":                 {options: []MethodOverrideOption{WithMethodGetter(MethodFromForm("_method"))}, method: http.MethodPost, body: "_method=PUT&name=foo", expect: http.MethodPut},
+		"query":                {options: []MethodOverrideOption{WithMethodGetter(MethodFromQuery("_method"))}, method: http.MethodPost, target: "/?_method=PATCH", expect: http.MethodPatch},
+		"first source wins":    {options: []MethodOverrideOption{WithMethodGetter(MethodFromQuery("_method"), MethodFromHeader("X-HTTP-Method-Override"))}, method: http.MethodPost, target: "/?_method=PATCH", header: http.Header{"X-Http-Method-Override": {"DELETE"}}, expect: http.MethodPatch},
+		"fallback next source": {options: []MethodOverrideOption{WithMethodGetter(MethodFromQuery("_method"), MethodFromHeader("X-HTTP-Method-Override"))}, method: http.MethodPost, header: http.Header{"X-Http-Method-Override": {"DELETE"}}, expect: http.MethodDelete},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var method, formValue string
+			handler := func(c webapp.Context) error {
+				method = c.Method()
+				formValue = c.Request().FormValue("name")
+				return c.NoContent()
+			}
+
+			app := webapp.New()
+			app.Pre(MethodOverride(test.options...))
+			app.Any("/", handler)
+
+			target := test.target
+			if target == "" {
+				target = "/"
+			}
+			req := httptest.NewRequest(test.method, target, strings.NewReader(test.body))
+			if test.body != "" {
+				req.Header.Set(webapp.HeaderContentType, webapp.MIMEApplicationForm)
+			}
+			for k, v := range test.header {
+				req.Header[k] = v
+			}
+			rw := httptest.NewRecorder()
+			app.ServeHTTP(rw, req)
+
+			assert.Equal(t, http.StatusNoContent, rw.Code)
+			assert.Equal(t, test.expect, method)
+			if test.body != "" {
+				assert.Equal(t, "foo", formValue)
+			}
+		})
+	}
+}
+
+func Test_method_override_routes_overridden_method(t *testing.T) {
+	app := webapp.New()
+	app.Pre(MethodOverride())
+	app.POST("/users/{id}", func(c webapp.Context) error { return c.String(http.StatusOK, "update") })
+	app.DELETE("/users/{id}", func(c webapp.Context) error { return c.String(http.StatusOK, "delete") })
+
+	req := httptest.NewRequest(http.MethodPost, "/users/1", nil)
+	req.Header.Set(webapp.HeaderXHTTPMethodOverride, http.MethodDelete)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.Equal(t, "delete", rw.Body.String())
+}
+
+func Test_method_override_multipart_form_memory(t *testing.T) {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	mw.WriteField("_method", "PUT")
+	fw, _ := mw.CreateFormFile("upload", "upload.txt")
+	fw.Write(bytes.Repeat([]byte("a"), 1024))
+	mw.Close()
+
+	var onDisk bool
+	app := webapp.New(webapp.WithMultipartMemory(64))
+	app.Pre(MethodOverride(WithMethodGetter(MethodFromForm("_method"))))
+	app.PUT("/", func(c webapp.Context) error {
+		fh, err := c.FormFile("upload")
+		if err != nil {
+			return err
+		}
+		f, err := fh.Open()
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, onDisk = f.(*os.File)
+		return c.NoContent()
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", body)
+	req.Header.Set(webapp.HeaderContentType, mw.FormDataContentType())
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusNoContent, rw.Code)
+	// the form is parsed with the multipart memory limit of the webapp, the file exceeding it is stored on disk
+	assert.True(t, onDisk)
+}
@@ -0,0 +1,122 @@
+package webapp
+
+import (
+	stdContext "context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MountOption configures how a webapp is mounted with Mount
+type MountOption func(*mount)
+
+// MountShared runs the mounted webapp in the context of the parent webapp. The error handler, binder, validator,
+// renderer and encoders of the parent are used, errors returned by the mounted routes are handled by the error handler
+// of the parent. The pre middleware and the routes of the mounted webapp are used.
+//
+// By default the mounted webapp is isolated, it serves the request as a http.Handler with its own context and services.
+func MountShared() MountOption {
+	return func(m *mount) {
+		m.shared = true
+	}
+}
+
+// mount strips the prefix from the request path before passing the request to the mounted handler
+type mount struct {
+	prefix  string
+	shared  bool
+	app     WebApp
+	handler http.Handler
+}
+
+func newMount(prefix string) *mount {
+	if len(prefix) < 2 || prefix[0] != '/' {
+		panic("mount prefix must begin with '/' and must not be the root in prefix '" + prefix + "'")
+	}
+	if strings.ContainsAny(prefix, "{}*") {
+		panic("mount prefix must not contain params in prefix '" + prefix + "'")
+	}
+	return &mount{prefix: strings.TrimSuffix(prefix, "/")}
+}
+
+type basePathKey struct{}
+
+// BasePath returns the prefix the webapp handling the request is mounted on, the prefixes of nested mounts are joined.
+// An empty string is returned when the request is not handled by a mounted webapp. The redirects of the routers and
+// static files add the base path to the location, a handler of a mounted webapp building a url from the request path
+// should do the same.
+func BasePath(r *http.Request) string {
+	basePath, _ := r.Context().Value(basePathKey{}).(string)
+	return basePath
+}
+
+// stripPrefix returns a shallow copy of the request with the prefix removed from the url path, the prefix is added to
+// the base path of the request
+func (m *mount) stripPrefix(r *http.Request) *http.Request {
+	u := new(url.URL)
+	*u = *r.URL
+	u.Path = strings.TrimPrefix(u.Path, m.prefix)
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	if u.RawPath != "" {
+		u.RawPath = strings.TrimPrefix(u.RawPath, m.prefix)
+		if u.RawPath == "" {
+			u.RawPath = "/"
+		}
+	}
+
+	r2 := r.WithContext(stdContext.WithValue(r.Context(), basePathKey{}, BasePath(r)+m.prefix))
+	r2.URL = u
+	return r2
+}
+
+func (m *mount) handle(c Context) error {
+	r := c.Request()
+	if !m.shared {
+		m.handler.ServeHTTP(c.Response(), m.stripPrefix(r))
+		return nil
+	}
+
+	// the mounted webapp routes the request with the same context, the params and the current route of the parent
+	// are restored for its middleware
+	pc := c.(ParamsContext)
+	paramNames := c.ParamNames()
+	paramValues := append([]string(nil), *pc.ParamValuesPtr()...)
+	currentRoute := c.CurrentRoute()
+	defer func() {
+		c.SetRequest(r)
+		pc.SetParamNames(paramNames...)
+		ps := pc.ParamValuesPtr()
+		*ps = append((*ps)[:0], paramValues...)
+		pc.SetCurrentRoute(currentRoute)
+	}()
+
+	c.SetRequest(m.stripPrefix(r))
+	return m.app.(Handler).Handle(c)
+}
+
+func (g *routeInfoGroup) Mount(prefix string, app WebApp, options ...MountOption) []RouteInfo {
+	m := newMount(prefix)
+	m.app = app
+	m.handler = app
+	for _, option := range options {
+		option(m)
+	}
+	if _, ok := app.(Handler); m.shared && !ok {
+		panic("a shared mounted webapp must implement the Handler interface")
+	}
+	return g.mount(m)
+}
+
+func (g *routeInfoGroup) MountHandler(prefix string, handler http.Handler) []RouteInfo {
+	m := newMount(prefix)
+	m.handler = handler
+	return g.mount(m)
+}
+
+// mount registers the handler for all the methods on the prefix and all the paths below the prefix
+func (g *routeInfoGroup) mount(m *mount) []RouteInfo {
+	routeInfos := g.Any(m.prefix, m.handle)
+	return append(routeInfos, g.Any(m.prefix+"/*path", m.handle)...)
+}
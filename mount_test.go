@@ -0,0 +1,169 @@
+package webapp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errTeapot = errors.New("teapot")
+
+func newBillingApp(options ...Option) WebApp {
+	billing := New(options...)
+	billing.GET("/", func(c Context) error { return c.String(http.StatusOK, "index "+c.Path()) })
+	billing.GET("/invoices/{id}", func(c Context) error { return c.String(http.StatusOK, "invoice "+c.Param("id")+" "+c.Path()) })
+	billing.POST("/invoices", func(c Context) error { return errTeapot })
+	return billing
+}
+
+// teapotErrorHandler responds with the status and the body to identify the webapp that handled the error
+func teapotErrorHandler(body string) Option {
+	return WithErrorHandler(func(c Context, err error) error {
+		if errors.Is(err, errTeapot) {
+			return c.String(http.StatusTeapot, body)
+		}
+		return err
+	}, DefaultErrorHandler)
+}
+
+func Test_webapp_mount(T *testing.T) {
+	tests := map[string]struct {
+		options []MountOption
+		method  string
+		target  string
+		code    int
+		body    string
+	}{
+		"prefix":                     {method: http.MethodGet, target: "/billing", code: 200, body: "index /"},
+		"prefix with trailing slash": {method: http.MethodGet, target: "/billing/", code: 200, body: "index /"},
+		"stripped path":              {method: http.MethodGet, target: "/billing/invoices/1", code: 200, body: "invoice 1 /invoices/1"},
+		"isolated error handler":     {method: http.MethodPost, target: "/billing/invoices", code: http.StatusTeapot, body: "billing"},
+		"shared error handler":       {options: []MountOption{MountShared()}, method: http.MethodPost, target: "/billing/invoices", code: http.StatusTeapot, body: "app"},
+		"shared stripped path":       {options: []MountOption{MountShared()}, method: http.MethodGet, target: "/billing/invoices/1", code: 200, body: "invoice 1 /invoices/1"},
+		"not found":                  {method: http.MethodGet, target: "/billing/unknown", code: 404},
+		"outside prefix":             {method: http.MethodGet, target: "/billingx", code: 404},
+	}
+
+	for name, test := range tests {
+		T.Run(name, func(T *testing.T) {
+			app := New(teapotErrorHandler("app"))
+			app.Mount("/billing", newBillingApp(teapotErrorHandler("billing")), test.options...)
+
+			rw := httptest.NewRecorder()
+			app.ServeHTTP(rw, httptest.NewRequest(test.method, test.target, nil))
+
+			assert.Equal(T, test.code, rw.Code)
+			if test.body != "" {
+				assert.Equal(T, test.body, rw.Body.String())
+			}
+		})
+	}
+}
+
+func Test_webapp_mount_shared_restores_request(T *testing.T) {
+	var paths, params, routes []string
+	app := New()
+	app.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			err := next(c)
+			paths = append(paths, c.Path())
+			params = append(params, c.Param("path"), c.Param("id"))
+			routes = append(routes, c.CurrentRoute().Path())
+			return err
+		}
+	})
+	app.Mount("/billing", newBillingApp(), MountShared())
+
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/billing/invoices/1", nil))
+
+	assert.Equal(T, http.StatusOK, rw.Code)
+	assert.Equal(T, []string{"/billing/invoices/1"}, paths)
+	assert.Equal(T, []string{"/invoices/1", ""}, params)
+	assert.Equal(T, []string{"/billing/*path"}, routes)
+}
+
+func Test_webapp_mount_base_path(T *testing.T) {
+	var basePaths []string
+	billing := New()
+	billing.GET("/invoices", func(c Context) error {
+		basePaths = append(basePaths, BasePath(c.Request()))
+		return c.NoContent()
+	})
+	billing.Static("/assets", newStaticFS())
+
+	app := New()
+	app.GET("/", func(c Context) error {
+		basePaths = append(basePaths, BasePath(c.Request()))
+		return c.NoContent()
+	})
+	app.Mount("/billing", billing)
+	app.Mount("/shared", billing, MountShared())
+	nested := New()
+	nested.Mount("/billing", billing)
+	app.Mount("/eu", nested)
+
+	for _, target := range []string{"/", "/billing/invoices", "/shared/invoices", "/eu/billing/invoices"} {
+		app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+	assert.Equal(T, []string{"", "/billing", "/shared", "/eu/billing"}, basePaths)
+
+	// the directory redirect of the static files keeps the mount prefix
+	tests := map[string]string{
+		"/billing/assets/images":    "/billing/assets/images/",
+		"/shared/assets/images":     "/shared/assets/images/",
+		"/eu/billing/assets/images": "/eu/billing/assets/images/",
+	}
+	for target, location := range tests {
+		T.Run(target, func(T *testing.T) {
+			rw := httptest.NewRecorder()
+			app.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, target, nil))
+
+			assert.Equal(T, http.StatusMovedPermanently, rw.Code)
+			assert.Equal(T, location, rw.Header().Get(HeaderLocation))
+		})
+	}
+}
+
+func Test_webapp_mount_handler(T *testing.T) {
+	var path, rawPath string
+	app := New()
+	app.MountHandler("/files/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, rawPath = r.URL.Path, r.URL.RawPath
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := map[string]struct {
+		method  string
+		target  string
+		path    string
+		rawPath string
+	}{
+		"prefix":        {method: http.MethodGet, target: "/files", path: "/"},
+		"nested":        {method: http.MethodGet, target: "/files/a/b.txt", path: "/a/b.txt"},
+		"other method":  {method: http.MethodDelete, target: "/files/a", path: "/a"},
+		"escaped slash": {method: http.MethodGet, target: "/files/a%2Fb", path: "/a/b", rawPath: "/a%2Fb"},
+	}
+
+	for name, test := range tests {
+		T.Run(name, func(T *testing.T) {
+			rw := httptest.NewRecorder()
+			app.ServeHTTP(rw, httptest.NewRequest(test.method, test.target, nil))
+
+			assert.Equal(T, http.StatusNoContent, rw.Code)
+			assert.Equal(T, test.path, path)
+			assert.Equal(T, test.rawPath, rawPath)
+		})
+	}
+}
+
+func Test_webapp_mount_invalid_prefix(T *testing.T) {
+	for _, prefix := range []string{"", "/", "billing", "/tenants/{id}", "/files/*path"} {
+		assert.Panics(T, func() {
+			New().MountHandler(prefix, http.NotFoundHandler())
+		}, "prefix %q", prefix)
+	}
+}
@@ -0,0 +1,197 @@
+package webapp
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ResponseEncoder writes the data as response body in the media type it is registered for
+type ResponseEncoder func(c Context, code int, data interface{}, n *Negotiation) error
+
+// Negotiation holds the options of a negotiated response
+type Negotiation struct {
+	// Template is the name of the template rendered for the text/html media type, html is only offered when a
+	// template and renderer are set
+	Template string
+
+	// Offers are the media types the response can be sent in, in order of preference. When empty the media types of
+	// the registered encodings are offered, followed by html.
+	Offers []string
+}
+
+// NegotiateOption configures a negotiated response
+type NegotiateOption func(*Negotiation)
+
+// NegotiateTemplate sets the template that is rendered when html is accepted
+func NegotiateTemplate(name string) NegotiateOption {
+	return func(n *Negotiation) {
+		n.Template = name
+	}
+}
+
+// NegotiateOffers restricts the media types the response can be sent in, in order of preference
+func NegotiateOffers(mediaTypes ...string) NegotiateOption {
+	return func(n *Negotiation) {
+		n.Offers = mediaTypes
+	}
+}
+
+// acceptRange is a media range of the Accept header
+type acceptRange struct {
+	mediaType string
+	subType   string
+	q         float64
+}
+
+// matches returns the specificity of the match with the media type, -1 when the range does not match
+func (a acceptRange) matches(mediaType string) int {
+	t, s, _ := strings.Cut(mediaType, "/")
+	switch {
+	case a.mediaType == "*" && a.subType == "*":
+		return 0
+	case a.mediaType != t:
+		return -1
+	case a.subType == "*":
+		return 1
+	case a.subType == s:
+		return 2
+	}
+	return -1
+}
+
+// parseAccept parses the values of the Accept header, the media ranges without a valid quality are skipped
+func parseAccept(values []string) []acceptRange {
+	var ranges []acceptRange
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			mediaRange, params, _ := strings.Cut(part, ";")
+			t, s, ok := strings.Cut(strings.ToLower(strings.TrimSpace(mediaRange)), "/")
+			if !ok || t == "" || s == "" {
+				continue
+			}
+
+			r := acceptRange{mediaType: t, subType: s, q: 1}
+			for _, param := range strings.Split(params, ";") {
+				key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if strings.EqualFold(key, "q") {
+					q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+					if err != nil || q < 0 || q > 1 {
+						r.q = -1
+					} else {
+						r.q = q
+					}
+				}
+			}
+			if r.q >= 0 {
+				ranges = append(ranges, r)
+			}
+		}
+	}
+	return ranges
+}
+
+// NegotiateMediaType returns the offered media type with the highest quality in the Accept header values, offers
+// with the same quality are picked in order of preference. The quality of an offer is taken from the most specific
+// matching media range. Without or with an empty Accept header the first offer is returned, an empty string is
+// returned when none of the offers is acceptable.
+func NegotiateMediaType(accept []string, offers []string) string {
+	ranges := parseAccept(accept)
+	if len(ranges) == 0 {
+		if len(offers) > 0 && strings.TrimSpace(strings.Join(accept, "")) == "" {
+			return offers[0]
+		}
+		return ""
+	}
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		specificity, q := -1, 0.0
+		for _, r := range ranges {
+			if s := r.matches(strings.ToLower(offer)); s > specificity {
+				specificity, q = s, r.q
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+func (c *context) Negotiate(code int, data interface{}, options ...NegotiateOption) error {
+	n := &Negotiation{}
+	for _, option := range options {
+		option(n)
+	}
+
+	offers := n.Offers
+	for _, offer := range offers {
+		if !strings.EqualFold(offer, MIMETextHTML) && c.webapp.encodings.get(offer) == nil {
+			return fmt.Errorf("%w for %q", ErrEncoderNotRegistered, offer)
+		}
+	}
+	if len(offers) == 0 {
+		offers = make([]string, 0, len(c.webapp.encodings)+1)
+		for _, encoding := range c.webapp.encodings {
+			offers = append(offers, encoding.MediaType())
+		}
+		if c.webapp.encodings.get(MIMETextHTML) == nil {
+			offers = append(offers, MIMETextHTML)
+		}
+	}
+
+	// html can only be rendered with a template, the other media types only when the encoding can encode the data
+	acceptable := make([]string, 0, len(offers))
+	for _, offer := range offers {
+		if encoding := c.webapp.encodings.get(offer); encoding != nil {
+			if canEncode(encoding, data) {
+				acceptable = append(acceptable, offer)
+			}
+		} else if n.Template != "" && c.webapp.renderer != nil {
+			acceptable = append(acceptable, offer)
+		}
+	}
+
+	addVary(c.response.Header(), HeaderAccept)
+	mediaType := NegotiateMediaType(c.request.Header.Values(HeaderAccept), acceptable)
+	encoding := c.webapp.encodings.get(mediaType)
+	if re, ok := encoding.(responseEncoding); ok {
+		return re.encoder(c, code, data, n)
+	}
+
+	switch {
+	case mediaType == "":
+		return ErrNotAcceptable
+	case strings.EqualFold(mediaType, MIMETextHTML):
+		return c.Render(code, n.Template, data)
+	case strings.EqualFold(mediaType, MIMEApplicationJSON):
+		return c.JSON(code, data)
+	case strings.EqualFold(mediaType, MIMEApplicationXML):
+		return c.XML(code, data)
+	}
+	return c.encode(code, encoding, data)
+}
+
+// encode sends the data encoded with the encoding, the content type is the media type of the encoding
+func (c *context) encode(code int, encoding Encoding, data interface{}) error {
+	buf := new(bytes.Buffer)
+	if err := encoding.Encode(buf, data); err != nil {
+		return err
+	}
+	return c.Blob(code, encoding.MediaType(), buf.Bytes())
+}
+
+// addVary adds the request header to the Vary response header when it is not listed yet
+func addVary(header http.Header, name string) {
+	for _, value := range header.Values(HeaderVary) {
+		for _, existing := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(existing), name) {
+				return
+			}
+		}
+	}
+	header.Add(HeaderVary, name)
+}
@@ -0,0 +1,200 @@
+package webapp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_negotiate_media_type(T *testing.T) {
+	offers := []string{MIMEApplicationJSON, MIMEApplicationXML, MIMETextHTML}
+
+	tests := map[string]struct {
+		accept []string
+		expect string
+	}{
+		"no accept header":          {accept: nil, expect: MIMEApplicationJSON},
+		"empty accept header":       {accept: []string{""}, expect: MIMEApplicationJSON},
+		"any":                       {accept: []string{"*/*"}, expect: MIMEApplicationJSON},
+		"exact":                     {accept: []string{"application/xml"}, expect: MIMEApplicationXML},
+		"case insensitive":          {accept: []string{"Application/XML"}, expect: MIMEApplicationXML},
+		"with params":               {accept: []string{"text/html;charset=utf-8"}, expect: MIMETextHTML},
+		"sub type wildcard":         {accept: []string{"text/*"}, expect: MIMETextHTML},
+		"highest quality":           {accept: []string{"application/json;q=0.5, application/xml;q=0.9"}, expect: MIMEApplicationXML},
+		"same quality server order": {accept: []string{"application/xml, application/json"}, expect: MIMEApplicationJSON},
+		"browser":                   {accept: []string{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"}, expect: MIMETextHTML},
+		"multiple headers":          {accept: []string{"application/json;q=0.1", "application/xml"}, expect: MIMEApplicationXML},
+		"most specific range wins":  {accept: []string{"*/*, application/json;q=0"}, expect: MIMEApplicationXML},
+		"excluded by zero quality":  {accept: []string{"application/json;q=0"}, expect: ""},
+		"invalid quality skipped":   {accept: []string{"application/json;q=2, application/xml;q=0.2"}, expect: MIMEApplicationXML},
+		"not acceptable":            {accept: []string{"image/png"}, expect: ""},
+		"invalid media range":       {accept: []string{"json"}, expect: ""},
+	}
+
+	for name, test := range tests {
+		T.Run(name, func(T *testing.T) {
+			assert.Equal(T, test.expect, NegotiateMediaType(test.accept, offers))
+		})
+	}
+}
+
+type negotiateRenderer struct{}
+
+func (negotiateRenderer) Render(c Context, w io.Writer, name string, data interface{}) error {
+	_, err := fmt.Fprintf(w, "<%s>%v</%s>", name, data.(responseUser).Name, name)
+	return err
+}
+
+func Test_context_negotiate_offer_without_encoder(T *testing.T) {
+	var err error
+	rw := serveContext(T, New(), httptest.NewRequest(http.MethodGet, "/", nil), func(c Context) error {
+		err = c.Negotiate(http.StatusOK, responseUser{}, NegotiateOffers(MIMEApplicationJSON, MIMEApplicationProtobuf))
+		return err
+	})
+
+	// an offer without encoder is a misconfiguration of the server, not a client error
+	assert.ErrorIs(T, err, ErrEncoderNotRegistered)
+	assert.ErrorContains(T, err, MIMEApplicationProtobuf)
+	assert.Equal(T, http.StatusInternalServerError, rw.Code)
+}
+
+func Test_context_negotiate_vary(T *testing.T) {
+	app := New()
+	app.With(MatchMediaType("application/vnd.acme.v2+json")).GET("/", func(c Context) error { return nil })
+	// the route matchers already set `Vary: Accept`
+	rw := serveContext(T, app, httptest.NewRequest(http.MethodGet, "/", nil), func(c Context) error {
+		c.Response().Header().Add(HeaderVary, "Accept-Encoding")
+		if err := c.Negotiate(http.StatusOK, responseUser{}, NegotiateOffers(MIMETextHTML)); err == nil {
+			return errors.New("expected not acceptable")
+		}
+		return c.Negotiate(http.StatusOK, responseUser{})
+	})
+
+	assert.Equal(T, http.StatusOK, rw.Code)
+	assert.Equal(T, []string{"Accept", "Accept-Encoding"}, rw.Header().Values(HeaderVary))
+}
+
+func Test_context_negotiate(T *testing.T) {
+	user := responseUser{ID: 1, Name: "foo"}
+	msgpack := func(c Context, code int, data interface{}, n *Negotiation) error {
+		return c.Blob(code, MIMEApplicationMsgpack, []byte("msgpack"))
+	}
+
+	tests := map[string]struct {
+		options     []Option
+		negotiate   []NegotiateOption
+		accept      string
+		code        int
+		contentType string
+		body        string
+	}{
+		"json by default": {
+			code:        http.StatusCreated,
+			contentType: MIMEApplicationJSONCharsetUTF8,
+			body:        "{\"id\":1,\"name\":\"foo\"}\n",
+		},
+		"xml": {
+			accept:      "application/json;q=0.5, application/xml",
+			code:        http.StatusCreated,
+			contentType: MIMEApplicationXMLCharsetUTF8,
+			body:        xmlHeader + "<user><id>1</id><name>foo</name></user>",
+		},
+		"html with template": {
+			options:     []Option{WithRenderer(negotiateRenderer{})},
+			negotiate:   []NegotiateOption{NegotiateTemplate("user")},
+			accept:      "text/html,application/xml;q=0.9",
+			code:        http.StatusCreated,
+			contentType: MIMETextHTMLCharsetUTF8,
+			body:        "<user>foo</user>",
+		},
+		"html without template": {
+			options: []Option{WithRenderer(negotiateRenderer{})},
+			accept:  "text/html",
+			code:    http.StatusNotAcceptable,
+		},
+		"html without renderer": {
+			negotiate: []NegotiateOption{NegotiateTemplate("user")},
+			accept:    "text/html",
+			code:      http.StatusNotAcceptable,
+		},
+		"registered encoder": {
+			options:     []Option{WithResponseEncoder(MIMEApplicationMsgpack, msgpack)},
+			accept:      "application/msgpack",
+			code:        http.StatusCreated,
+			contentType: MIMEApplicationMsgpack,
+			body:        "msgpack",
+		},
+		"replaced encoder": {
+			options:     []Option{WithResponseEncoder(MIMEApplicationJSON, msgpack)},
+			code:        http.StatusCreated,
+			contentType: MIMEApplicationMsgpack,
+			body:        "msgpack",
+		},
+		"registered encoding": {
+			options:     []Option{WithEncoding(textEncoding{mediaType: MIMEApplicationMsgpack})},
+			accept:      "application/msgpack",
+			code:        http.StatusCreated,
+			contentType: MIMEApplicationMsgpack,
+			body:        "application/msgpack:{{} 1 foo}",
+		},
+		"replaced json encoding": {
+			options:     []Option{WithEncoding(textEncoding{mediaType: MIMEApplicationJSON})},
+			code:        http.StatusCreated,
+			contentType: MIMEApplicationJSONCharsetUTF8,
+			body:        "application/json:{{} 1 foo}",
+		},
+		"encoding cannot encode": {
+			options:     []Option{WithEncoding(intEncoding{textEncoding{mediaType: MIMEApplicationMsgpack}})},
+			accept:      "application/msgpack, application/xml;q=0.5",
+			code:        http.StatusCreated,
+			contentType: MIMEApplicationXMLCharsetUTF8,
+			body:        xmlHeader + "<user><id>1</id><name>foo</name></user>",
+		},
+		"only encoding that cannot encode": {
+			options: []Option{WithEncoding(intEncoding{textEncoding{mediaType: MIMEApplicationMsgpack}})},
+			accept:  "application/msgpack",
+			code:    http.StatusNotAcceptable,
+		},
+		"restricted offers": {
+			negotiate:   []NegotiateOption{NegotiateOffers(MIMEApplicationXML)},
+			accept:      "*/*",
+			code:        http.StatusCreated,
+			contentType: MIMEApplicationXMLCharsetUTF8,
+			body:        xmlHeader + "<user><id>1</id><name>foo</name></user>",
+		},
+		"not acceptable": {
+			accept: "image/png",
+			code:   http.StatusNotAcceptable,
+		},
+	}
+
+	for name, test := range tests {
+		T.Run(name, func(T *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.accept != "" {
+				req.Header.Set(HeaderAccept, test.accept)
+			}
+
+			var err error
+			rw := serveContext(T, New(test.options...), req, func(c Context) error {
+				err = c.Negotiate(http.StatusCreated, user, test.negotiate...)
+				return err
+			})
+
+			assert.Equal(T, test.code, rw.Code)
+			assert.Equal(T, []string{HeaderAccept}, rw.Header().Values(HeaderVary))
+			if test.code == http.StatusNotAcceptable {
+				assert.ErrorIs(T, err, ErrNotAcceptable)
+				return
+			}
+			assert.NoError(T, err)
+			assert.Equal(T, test.contentType, rw.Header().Get(HeaderContentType))
+			assert.Equal(T, test.body, rw.Body.String())
+		})
+	}
+}
@@ -0,0 +1,5 @@
+//go:build !race
+
+package webapp
+
+const raceEnabled = false
@@ -0,0 +1,97 @@
+package webapp
+
+import (
+	"sort"
+	"strings"
+)
+
+// NotFoundRoutes are the routes registered with RouteNotFound ordered by the longest prefix, used by the routers to
+// find the not found handler for a path without a matching route
+type NotFoundRoutes struct {
+	routes []*notFoundRoute
+}
+
+// notFoundRoute is a route registered with RouteNotFound, it matches all the paths with the prefix
+type notFoundRoute struct {
+	route    RouteInfo
+	handler  HandlerFunc
+	path     string
+	prefix   string
+	catchAll bool
+}
+
+// match reports if the path is within the scope of the route, the value is the remaining path for the catch-all
+// param including the leading slash
+func (n *notFoundRoute) match(path string) (string, bool) {
+	if !n.catchAll {
+		return "", path == n.prefix
+	}
+	if strings.HasPrefix(path, n.prefix) {
+		return path[len(n.prefix)-1:], true
+	}
+	return "", path == n.prefix[:len(n.prefix)-1]
+}
+
+// Add registers the not found handler for the path, a path ending with a catch-all `/*name` matches all the paths
+// below the prefix. The route info of the router is created with the param names of the path. It panics when the
+// path is invalid or a not found handler is already registered for the path.
+func (n *NotFoundRoutes) Add(path string, routeInfo func(params []string) RouteInfo, handler HandlerFunc, middleware ...MiddlewareFunc) RouteInfo {
+	if len(path) < 1 || path[0] != '/' {
+		panic("path must begin with '/' in path '" + path + "'")
+	}
+	if handler == nil {
+		panic("handler must not be nil")
+	}
+	if strings.IndexByte(path, '{') >= 0 {
+		panic("params are not supported in not found route '" + path + "'")
+	}
+
+	route := &notFoundRoute{
+		handler: applyMiddleware(handler, middleware...),
+		path:    path,
+		prefix:  path,
+	}
+	params := []string{}
+
+	if i := strings.IndexByte(path, '*'); i >= 0 {
+		if path[i-1] != '/' {
+			panic("no / before catch-all in path '" + path + "'")
+		}
+		if name := path[i+1:]; name != "" {
+			if strings.ContainsAny(name, "/*") {
+				panic("catch-all routes are only allowed at the end of the path in path '" + path + "'")
+			}
+			params = []string{name}
+		}
+		route.prefix = path[:i]
+		route.catchAll = true
+	}
+
+	for _, existing := range n.routes {
+		if existing.path == path {
+			panic("a not found handle is already registered for path '" + path + "'")
+		}
+	}
+
+	route.route = routeInfo(params)
+	n.routes = append(n.routes, route)
+	sort.SliceStable(n.routes, func(i, j int) bool {
+		return len(n.routes[i].prefix) > len(n.routes[j].prefix)
+	})
+
+	return route.route
+}
+
+// Find returns the handler and route info of the not found route with the longest prefix matching the path, the
+// param values hold the remaining path for a named catch-all. A nil handler is returned when no route matches.
+func (n *NotFoundRoutes) Find(path string) (HandlerFunc, RouteInfo, []string) {
+	for _, route := range n.routes {
+		if value, ok := route.match(path); ok {
+			if len(route.route.Params()) > 0 {
+				return route.handler, route.route, []string{value}
+			}
+			return route.handler, route.route, nil
+		}
+	}
+	return nil, nil, nil
+}
@@ -34,12 +34,48 @@ func WithErrorHandlerFallback(errorHandlers ...ErrorHandler) Option {
 	return WithErrorHandler(append(errorHandlers, DefaultErrorHandler)...)
 }
 
+// WithRecover enables or disables the recovery of panics in the handlers, recovery is enabled by default.
+// A recovered panic is passed as a *PanicError to the error handler, the default error handler responds with an
+// internal server error. When the error handler cannot handle the error and the headers are not sent yet, an internal
+// server error is sent.
+// With recovery disabled, the panic is handled by the http server which closes the connection.
+func WithRecover(recover bool) Option {
+	return func(app WebApp) {
+		app.(*webapp).recover = recover
+	}
+}
+
+// WithMultipartMemory sets the maximum number of bytes of a multipart form that are stored in memory, the remaining
+// file parts are stored in temporary files. The default is DefaultMultipartMemory.
+func WithMultipartMemory(maxMemory int64) Option {
+	return func(app WebApp) {
+		app.(*webapp).multipartMemory = maxMemory
+	}
+}
+
+// WithIPExtractor sets the extractor used by Context.RealIP, by default the remote address of the connection is used
+// (see ExtractIPDirect). Behind a proxy use ExtractIPFromXFFHeader, ExtractIPFromRealIPHeader or
+// ExtractIPFromForwardedHeader with the proxies that are trusted to set the header.
+func WithIPExtractor(extractor IPExtractor) Option {
+	return func(app WebApp) {
+		app.(*webapp).ipExtractor = extractor
+	}
+}
+
 func WithRouter(router Router) Option {
 	return func(app WebApp) {
 		app.(*webapp).router = router
 	}
 }
 
+// WithRouterFactory sets the factory that creates the router for every host pattern registered with Host, by default
+// the default router is used.
+func WithRouterFactory(factory func() Router) Option {
+	return func(app WebApp) {
+		app.(*webapp).routerFactory = factory
+	}
+}
+
 func WithBinder(binder Binder) Option {
 	return func(app WebApp) {
 		app.(*webapp).binder = binder
@@ -48,7 +84,41 @@ func WithBinder(binder Binder) Option {
 
 func WithJsonEncoder(encoder JSONEncoding) Option {
 	return func(app WebApp) {
-		app.(*webapp).jsonEncoder = encoder
+		a := app.(*webapp)
+		a.encodings = a.encodings.setEncoder(MIMEApplicationJSON, encoder)
+	}
+}
+
+func WithXmlEncoder(encoder XMLEncoding) Option {
+	return func(app WebApp) {
+		a := app.(*webapp)
+		a.encodings = a.encodings.setEncoder(MIMEApplicationXML, encoder)
+	}
+}
+
+// WithResponseEncoder registers the encoder of the media type for the negotiated responses, the request bodies are
+// still decoded with the encoding registered before for the media type. A later registration of the same media type
+// replaces the encoder. New media types are offered after the registered encodings.
+func WithResponseEncoder(mediaType string, encoder ResponseEncoder) Option {
+	return func(app WebApp) {
+		a := app.(*webapp)
+		previous := a.encodings.get(mediaType)
+		if re, ok := previous.(responseEncoding); ok {
+			previous = re.encoding
+		}
+		a.encodings = a.encodings.set(responseEncoding{mediaType: mediaType, encoder: encoder, encoding: previous})
+	}
+}
+
+// WithEncoding registers the encodings for the request bodies decoded by the binder and the responses sent by
+// Context.Negotiate, an encoding of the same media type is replaced. Replacing the json or xml encoding also changes
+// the encoding used by Context.JSON and Context.XML, like WithJsonEncoder and WithXmlEncoder.
+func WithEncoding(encodings ...Encoding) Option {
+	return func(app WebApp) {
+		a := app.(*webapp)
+		for _, encoding := range encodings {
+			a.encodings = a.encodings.set(encoding)
+		}
 	}
 }
 
@@ -0,0 +1,36 @@
+package webapp
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is the error passed to the error handler when a panic is recovered.
+type PanicError struct {
+	// Value is the value the handler panicked with
+	Value interface{}
+
+	// Stack is the stack trace of the goroutine that panicked
+	Stack []byte
+}
+
+// NewPanicError creates a new PanicError for the recovered value with the stack trace of the current goroutine.
+func NewPanicError(value interface{}) *PanicError {
+	return &PanicError{
+		Value: value,
+		Stack: debug.Stack(),
+	}
+}
+
+// Error makes it compatible with `error` interface.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v\n%s", e.Value, e.Stack)
+}
+
+// Unwrap returns the recovered value when the handler panicked with an error.
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
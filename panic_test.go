@@ -0,0 +1,122 @@
+package webapp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_webapp_recovers_panic_with_internal_server_error(T *testing.T) {
+	app := New()
+	app.GET("/panic", func(c Context) error {
+		var m map[string]string
+		m["nil"] = "dereference"
+		return nil
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/panic", nil)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.Equal(T, http.StatusInternalServerError, rw.Code)
+	assert.JSONEq(T, `{"message":"Internal Server Error"}`, rw.Body.String())
+}
+
+func Test_webapp_recovered_panic_is_passed_to_error_handler(T *testing.T) {
+	var handledErr error
+	app := New(WithErrorHandler(func(c Context, err error) error {
+		handledErr = err
+		return c.String(http.StatusServiceUnavailable, "handled")
+	}))
+
+	cause := errors.New("cause")
+	app.GET("/panic", func(c Context) error {
+		panic(cause)
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/panic", nil)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.Equal(T, http.StatusServiceUnavailable, rw.Code)
+
+	var panicErr *PanicError
+	require.ErrorAs(T, handledErr, &panicErr)
+	assert.Equal(T, cause, panicErr.Value)
+	assert.ErrorIs(T, handledErr, cause)
+	assert.Contains(T, string(panicErr.Stack), "Test_webapp_recovered_panic_is_passed_to_error_handler")
+}
+
+func Test_webapp_recovered_panic_unhandled_by_error_handler(T *testing.T) {
+	app := New(WithErrorHandler(func(c Context, err error) error {
+		return err
+	}))
+	app.GET("/panic", func(c Context) error {
+		panic("boom")
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/panic", nil)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.Equal(T, http.StatusInternalServerError, rw.Code)
+}
+
+func Test_webapp_recovered_panic_after_headers_sent(T *testing.T) {
+	app := New(WithErrorHandler(func(c Context, err error) error {
+		return err
+	}))
+	app.GET("/panic", func(c Context) error {
+		c.Response().WriteHeader(http.StatusAccepted)
+		panic("boom")
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/panic", nil)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.Equal(T, http.StatusAccepted, rw.Code)
+}
+
+func Test_webapp_recovers_panic_in_pre_middleware(T *testing.T) {
+	app := New()
+	app.Pre(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			panic("boom")
+		}
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.Equal(T, http.StatusInternalServerError, rw.Code)
+}
+
+func Test_webapp_does_not_recover_abort_handler(T *testing.T) {
+	app := New()
+	app.GET("/abort", func(c Context) error {
+		panic(http.ErrAbortHandler)
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/abort", nil)
+	assert.PanicsWithValue(T, http.ErrAbortHandler, func() {
+		app.ServeHTTP(httptest.NewRecorder(), req)
+	})
+}
+
+func Test_webapp_recover_disabled(T *testing.T) {
+	app := New(WithRecover(false))
+	app.GET("/panic", func(c Context) error {
+		panic("boom")
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/panic", nil)
+	assert.PanicsWithValue(T, "boom", func() {
+		app.ServeHTTP(httptest.NewRecorder(), req)
+	})
+}
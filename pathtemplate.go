@@ -0,0 +1,251 @@
+package webapp
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// PathTemplate is a parsed route path used to generate urls from param values.
+// Params are written as `{name}` and a catch-all param as `*name` at the end of the path.
+//
+// A param can have a constraint, `{id:int}`, `{id:uuid}` or a regular expression like `{slug:[a-z-]+}`, and the last
+// param of the path can be optional, `{page:int?}`.
+type PathTemplate struct {
+	path     string
+	segments []pathSegment
+	params   []string
+	specs    []ParamSpec
+
+	// optionalStart is the offset of the optional param in the path, 0 when the path has no optional param
+	optionalStart int
+}
+
+type pathSegment struct {
+	value string
+	param int
+}
+
+// ParamSpec describes a param in the route path
+type ParamSpec struct {
+	// Name of the param
+	Name string
+
+	// Constraint the value must satisfy, `int`, `uuid` or a regular expression. Empty when the param matches any value.
+	Constraint string
+
+	// Optional params can be omitted from the path, only the last param of a path can be optional
+	Optional bool
+
+	// CatchAll is set for the `*name` param which matches the rest of the path
+	CatchAll bool
+
+	match func(string) bool
+}
+
+// Match reports if the value satisfies the constraint of the param
+func (p ParamSpec) Match(value string) bool {
+	return p.match == nil || p.match(value)
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ParseParamSpec parses the param between the braces, `id`, `id:int` or `page:int?`. The `?` marks the param as
+// optional on a param without constraint or with the `int` or `uuid` constraint, a regular expression ending with `?`
+// like `code:[a-z]?` keeps it as part of the expression.
+// It panics when the constraint is not a valid regular expression.
+func ParseParamSpec(param string) ParamSpec {
+	name, constraint, found := strings.Cut(param, ":")
+	spec := ParamSpec{Name: name}
+	if !found {
+		if strings.HasSuffix(name, "?") {
+			spec.Optional = true
+			spec.Name = name[:len(name)-1]
+		}
+		return spec
+	}
+
+	switch constraint {
+	case "?", "int?", "uuid?":
+		spec.Optional = true
+		constraint = constraint[:len(constraint)-1]
+	}
+
+	spec.Constraint = constraint
+	switch constraint {
+	case "":
+	case "int":
+		spec.match = isInt
+	case "uuid":
+		spec.match = uuidPattern.MatchString
+	default:
+		spec.match = regexp.MustCompile("^(?:" + constraint + ")$").MatchString
+	}
+	return spec
+}
+
+func isInt(value string) bool {
+	if len(value) > 0 && value[0] == '-' {
+		value = value[1:]
+	}
+	if value == "" {
+		return false
+	}
+	for i := 0; i < len(value); i++ {
+		if value[i] < '0' || value[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// ParamEnd returns the offset of the closing brace of the param starting at the beginning of the path, the braces in
+// the constraint are skipped. -1 is returned when the param is not closed.
+func ParamEnd(path string) int {
+	depth := 0
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// ParsePathTemplate parses the route path into a template, the path is not validated, that is the responsibility of
+// the router. It panics when a constraint is not a valid regular expression.
+func ParsePathTemplate(path string) *PathTemplate {
+	t := &PathTemplate{
+		path:   path,
+		params: []string{},
+		specs:  []ParamSpec{},
+	}
+
+	offset := 0
+	for offset < len(path) {
+		rest := path[offset:]
+		i := strings.IndexAny(rest, "{*")
+		if i < 0 {
+			t.segments = append(t.segments, pathSegment{value: rest, param: -1})
+			break
+		}
+		if i > 0 {
+			t.segments = append(t.segments, pathSegment{value: rest[:i], param: -1})
+		}
+
+		if rest[i] == '*' {
+			t.addParam(ParamSpec{Name: rest[i+1:], CatchAll: true})
+			break
+		}
+
+		end := ParamEnd(rest[i:])
+		if end < 0 {
+			t.segments = append(t.segments, pathSegment{value: rest[i:], param: -1})
+			break
+		}
+
+		spec := ParseParamSpec(rest[i+1 : i+end])
+		if spec.Optional {
+			t.optionalStart = offset + i
+		}
+		t.addParam(spec)
+		offset += i + end + 1
+	}
+	return t
+}
+
+func (t *PathTemplate) addParam(spec ParamSpec) {
+	t.segments = append(t.segments, pathSegment{param: len(t.specs)})
+	t.params = append(t.params, spec.Name)
+	t.specs = append(t.specs, spec)
+}
+
+// Path returns the route path the template was parsed from
+func (t *PathTemplate) Path() string {
+	return t.path
+}
+
+// Params returns the param names in the order they appear in the path
+func (t *PathTemplate) Params() []string {
+	return t.params
+}
+
+// ParamSpecs returns the params with their constraint in the order they appear in the path
+func (t *PathTemplate) ParamSpecs() []ParamSpec {
+	return t.specs
+}
+
+// Variants returns the paths the router has to match for the route. A path with an optional param results in the path
+// with the param and the path without the param, `/posts/{page:int?}` results in `/posts/{page:int}` and `/posts`.
+// It panics when the optional param is not the last segment of the path.
+func (t *PathTemplate) Variants() []string {
+	if t.optionalStart == 0 {
+		return []string{t.path}
+	}
+
+	last := t.segments[len(t.segments)-1]
+	if last.param < 0 || !t.specs[last.param].Optional || t.path[t.optionalStart-1] != '/' {
+		panic("optional params are only allowed as the last segment of the path in path '" + t.path + "'")
+	}
+	for _, spec := range t.specs[:len(t.specs)-1] {
+		if spec.Optional {
+			panic("only one optional param is allowed in path '" + t.path + "'")
+		}
+	}
+
+	without := t.path[:t.optionalStart-1]
+	if without == "" {
+		without = "/"
+	}
+	return []string{t.path[:len(t.path)-2] + "}", without}
+}
+
+// Expand generates the url path with the params in the order they appear in the path. Param values are escaped as a
+// path segment, the value of a catch-all param may contain slashes and is escaped per segment.
+// An error is returned when the number of values does not match the number of params, an optional param can be
+// omitted, or when a value does not satisfy the constraint of its param.
+func (t *PathTemplate) Expand(params ...interface{}) (string, error) {
+	if len(params) < len(t.params) && !(len(params) == len(t.params)-1 && t.specs[len(params)].Optional) {
+		return "", fmt.Errorf("%w: %q for path %s", ErrReverseMissingParam, t.params[len(params)], t.path)
+	}
+	if len(params) > len(t.params) {
+		return "", fmt.Errorf("%w: got %d values for %d params in path %s", ErrReverseExtraParam, len(params), len(t.params), t.path)
+	}
+
+	sb := strings.Builder{}
+	sb.Grow(len(t.path))
+	for _, s := range t.segments {
+		switch {
+		case s.param < 0:
+			sb.WriteString(s.value)
+		case s.param >= len(params):
+			// the optional param is omitted together with the slash before it
+			uri := strings.TrimSuffix(sb.String(), "/")
+			if uri == "" {
+				uri = "/"
+			}
+			return uri, nil
+		case t.specs[s.param].CatchAll:
+			// the catch-all value includes the leading slash when taken from the request path
+			value := strings.TrimPrefix(fmt.Sprint(params[s.param]), "/")
+			segments := strings.Split(value, "/")
+			for j := range segments {
+				segments[j] = url.PathEscape(segments[j])
+			}
+			sb.WriteString(strings.Join(segments, "/"))
+		default:
+			value := fmt.Sprint(params[s.param])
+			if spec := t.specs[s.param]; !spec.Match(value) {
+				return "", fmt.Errorf("%w: %q for %s in path %s", ErrReverseInvalidParam, value, spec.Name, t.path)
+			}
+			sb.WriteString(url.PathEscape(value))
+		}
+	}
+	return sb.String(), nil
+}
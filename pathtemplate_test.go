@@ -0,0 +1,131 @@
+package webapp
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_path_template_expand(T *testing.T) {
+	tests := map[string]struct {
+		path   string
+		params []interface{}
+		uri    string
+		err    error
+	}{
+		"static":             {path: "/users", uri: "/users"},
+		"param":              {path: "/users/{id}", params: []interface{}{12}, uri: "/users/12"},
+		"multiple params":    {path: "/users/{id}/posts/{post}", params: []interface{}{12, "hello"}, uri: "/users/12/posts/hello"},
+		"intention":          {path: "/intention/{id}:rename", params: []interface{}{"abc"}, uri: "/intention/abc:rename"},
+		"escaped value":      {path: "/users/{name}", params: []interface{}{"john doe/?"}, uri: "/users/john%20doe%2F%3F"},
+		"percent in literal": {path: "/100%/{id}", params: []interface{}{1}, uri: "/100%/1"},
+		"catch-all":          {path: "/files/*filepath", params: []interface{}{"/templates/article.html"}, uri: "/files/templates/article.html"},
+		"catch-all relative": {path: "/files/*filepath", params: []interface{}{"css/main file.css"}, uri: "/files/css/main%20file.css"},
+		"constraint":         {path: "/users/{id:int}/tags/{slug:[a-z]{2,}}", params: []interface{}{12, "go"}, uri: "/users/12/tags/go"},
+		"optional":           {path: "/posts/{page:int?}", params: []interface{}{2}, uri: "/posts/2"},
+		"optional omitted":   {path: "/posts/{page:int?}", uri: "/posts"},
+		"missing param":      {path: "/users/{id}/posts/{post}", params: []interface{}{12}, err: ErrReverseMissingParam},
+		"extra param":        {path: "/users/{id}", params: []interface{}{12, 13}, err: ErrReverseExtraParam},
+		"invalid int":        {path: "/users/{id:int}", params: []interface{}{"abc"}, err: ErrReverseInvalidParam},
+		"invalid regexp":     {path: "/tags/{slug:[a-z]{2,}}", params: []interface{}{"g"}, err: ErrReverseInvalidParam},
+		"invalid optional":   {path: "/posts/{page:int?}", params: []interface{}{"first"}, err: ErrReverseInvalidParam},
+	}
+
+	for name, test := range tests {
+		T.Run(name, func(T *testing.T) {
+			uri, err := ParsePathTemplate(test.path).Expand(test.params...)
+			if test.err != nil {
+				assert.ErrorIs(T, err, test.err)
+				return
+			}
+			require.NoError(T, err)
+			assert.Equal(T, test.uri, uri)
+		})
+	}
+}
+
+func Test_routes_reverse(T *testing.T) {
+	showUser := func(c Context) error { return nil }
+	webhook := func(c Context) error { return nil }
+	showPost := func(c Context) error { return nil }
+
+	app := New()
+	app.GET("/users/{id}", showUser)
+	app.GET("/posts/{id:int}", showPost)
+	app.Match([]string{http.MethodGet, http.MethodPost}, "/hooks/{name}", webhook)
+
+	uri, err := app.Routes().Reverse(handlerName(showUser), 12)
+	require.NoError(T, err)
+	assert.Equal(T, "/users/12", uri)
+
+	uri, err = app.Routes().URI(webhook, "github")
+	require.NoError(T, err)
+	assert.Equal(T, "/hooks/github", uri)
+
+	uri, err = app.Routes().ReverseWithQuery(handlerName(showUser), []interface{}{12}, url.Values{"tab": []string{"posts"}})
+	require.NoError(T, err)
+	assert.Equal(T, "/users/12?tab=posts", uri)
+
+	_, err = app.Routes().URI(showPost, "abc")
+	assert.ErrorIs(T, err, ErrReverseInvalidParam)
+
+	_, err = app.Routes().Reverse("unknown")
+	assert.ErrorIs(T, err, ErrRouteNotFound)
+
+	_, err = app.Routes().URI(func(c Context) error { return nil })
+	assert.ErrorIs(T, err, ErrRouteNotFound)
+}
+
+func Test_path_template_param_specs(T *testing.T) {
+	t := ParsePathTemplate("/users/{id:int}/{slug:[a-z]{2,}}/*rest")
+
+	assert.Equal(T, []string{"id", "slug", "rest"}, t.Params())
+	specs := t.ParamSpecs()
+	require.Len(T, specs, 3)
+	assert.Equal(T, "int", specs[0].Constraint)
+	assert.True(T, specs[0].Match("12"))
+	assert.False(T, specs[0].Match("twelve"))
+	assert.Equal(T, "[a-z]{2,}", specs[1].Constraint)
+	assert.True(T, specs[1].Match("go"))
+	assert.False(T, specs[1].Match("g"))
+	assert.True(T, specs[2].CatchAll)
+
+	assert.Equal(T, []string{"/posts/{page:int}", "/posts"}, ParsePathTemplate("/posts/{page:int?}").Variants())
+	assert.Equal(T, []string{"/{page}", "/"}, ParsePathTemplate("/{page?}").Variants())
+}
+
+func Test_parse_param_spec(T *testing.T) {
+	tests := map[string]struct {
+		param    string
+		name     string
+		optional bool
+		match    []string
+		noMatch  []string
+	}{
+		"param":                     {param: "id", name: "id", match: []string{"abc"}},
+		"optional":                  {param: "id?", name: "id", optional: true, match: []string{"abc"}},
+		"int":                       {param: "id:int", name: "id", match: []string{"12"}, noMatch: []string{"abc"}},
+		"optional int":              {param: "page:int?", name: "page", optional: true, match: []string{"2"}, noMatch: []string{"two"}},
+		"optional uuid":             {param: "id:uuid?", name: "id", optional: true, match: []string{"6ba7b810-9dad-11d1-80b4-00c04fd430c8"}, noMatch: []string{"12"}},
+		"regex ending with ?":       {param: "code:[a-z]?", name: "code", match: []string{"", "a"}, noMatch: []string{"ab", "1"}},
+		"lazy regex quantifier":     {param: `v:\d+?`, name: "v", match: []string{"1", "123"}, noMatch: []string{"", "a"}},
+		"optional empty constraint": {param: "id:?", name: "id", optional: true, match: []string{"abc"}},
+	}
+
+	for name, test := range tests {
+		T.Run(name, func(T *testing.T) {
+			spec := ParseParamSpec(test.param)
+			assert.Equal(T, test.name, spec.Name)
+			assert.Equal(T, test.optional, spec.Optional)
+			for _, value := range test.match {
+				assert.True(T, spec.Match(value), value)
+			}
+			for _, value := range test.noMatch {
+				assert.False(T, spec.Match(value), value)
+			}
+		})
+	}
+}
@@ -1,19 +1,30 @@
 package webapp
 
 import (
+	"io/fs"
 	"net/http"
 )
 
-type Router interface {
-	// RouteNotFound registers a special-case route which is executed when no other route is found (i.e. HTTP 404 cases)
-	// for current request URL.
-	// Path supports static and named/any parameters just like other http method is defined. Generally path is ended with
-	// wildcard/match-any character (`/*`, `/download/*` etc).
-	//
-	// Example: `e.RouteNotFound("/*", func(c webapp.Context) error { return c.NoContent(http.StatusNotFound) })`
-	RouteNotFound(path string, h HandlerFunc, m ...MiddlewareFunc) RouteInfo
+// Methods are the standard HTTP methods supported by the routers.
+var Methods = []string{
+	http.MethodConnect,
+	http.MethodDelete,
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodOptions,
+	http.MethodPatch,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodTrace,
+}
+
+// RouteNotFoundMethod is the method of the routes registered with RouteNotFound
+const RouteNotFoundMethod = "ROUTE_NOT_FOUND"
 
-	Lookup(method, path string) (HandlerFunc, RouteInfo)
+type Router interface {
+	// Lookup returns the handler and route registered for the method and path, together with the param values
+	// extracted from the path in the same order as the route params. Nil is returned when no route matches.
+	Lookup(method, path string) (HandlerFunc, RouteInfo, []string)
 
 	Handler
 
@@ -21,6 +32,16 @@ type Router interface {
 }
 
 type RouteGroup interface {
+	// RouteNotFound registers a special-case route which is executed when no other route is found (i.e. HTTP 404 cases)
+	// for current request URL.
+	// Path is a static path, or a static prefix ended with a wildcard/match-any character (`/*`, `/download/*` etc).
+	// The wildcard can be named to retrieve the remaining path as a param (`/download/*filepath`).
+	// When multiple routes match, the route with the longest prefix is executed. The prefix without the trailing
+	// slash matches as well, `/download/*` matches `/download`.
+	//
+	// Example: `e.RouteNotFound("/*", func(c webapp.Context) error { return c.String(http.StatusNotFound, "not found") })`
+	RouteNotFound(path string, h HandlerFunc, m ...MiddlewareFunc) RouteInfo
+
 	// Use adds middleware to the chain.
 	// Important!: only routes registered after the use command will have the middleware, previous registered
 	// routes are untouched
@@ -64,15 +85,30 @@ type RouteGroup interface {
 
 	// Any registers a new route for all HTTP methods and path with matching handler
 	// in the router with optional route-level middleware.
+	// One route is returned per method, named with the method qualified handler name (see MethodQualifiedName).
 	Any(path string, handler HandlerFunc, middleware ...MiddlewareFunc) []RouteInfo
 
 	// Match registers a new route for multiple HTTP methods and path with matching
 	// handler in the router with optional route-level middleware.
+	// One route is returned per method, named with the method qualified handler name (see MethodQualifiedName).
 	Match(methods []string, path string, handler HandlerFunc, middleware ...MiddlewareFunc) []RouteInfo
 
+	// Static serves the files of the file system, like an embed.FS or os.DirFS, for the GET and HEAD requests of the
+	// prefix and all the paths below the prefix. See StaticHandler for the supported features.
+	//
+	// Example: `app.Static("/assets", assetsFS, webapp.StaticIndexFallback())`
+	Static(prefix string, fsys fs.FS, options ...StaticOption) []RouteInfo
+
 	// Group creates a new router group with prefix and optional group-level middleware.
 	Group(prefix string, m ...MiddlewareFunc) RouteGroup
 
+	// With returns a route group for the same prefix whose routes also require the matchers to match the request.
+	// Routes with the same method and path can be registered with other matchers, the route with the most matchers
+	// that all match is used, a route without matchers handles the remaining requests.
+	//
+	// Example: `app.With(webapp.MatchMediaType("application/vnd.acme.v2+json")).GET("/users", listUsersV2)`
+	With(matchers ...RouteMatcher) RouteGroup
+
 	// Add registers a new route for an HTTP method and path with matching handler
 	// in the router with optional route-level middleware.
 	Add(method, path string, handler HandlerFunc, middleware ...MiddlewareFunc) RouteInfo
@@ -80,10 +116,19 @@ type RouteGroup interface {
 
 // routeInfoGroup is a wrapper for the router route group to manage the returned routes
 type routeInfoGroup struct {
-	routes routes
+	app *webapp
 	RouteGroup
 }
 
+// register adds the route to the registry and keeps track of the largest number of params of a route, used to
+// preallocate the param values of the pooled contexts
+func (g *routeInfoGroup) register(routeInfo RouteInfo) RouteInfo {
+	if numParams := len(routeInfo.Params()); numParams > g.app.maxParams {
+		g.app.maxParams = numParams
+	}
+	return g.app.routes.register(routeInfo)
+}
+
 func (g *routeInfoGroup) CONNECT(path string, h HandlerFunc, m ...MiddlewareFunc) RouteInfo {
 	return g.Add(http.MethodConnect, path, h, m...)
 }
@@ -121,32 +166,50 @@ func (g *routeInfoGroup) TRACE(path string, h HandlerFunc, m ...MiddlewareFunc)
 }
 
 func (g *routeInfoGroup) Any(path string, handler HandlerFunc, middleware ...MiddlewareFunc) []RouteInfo {
-	//TODO implement me
-	panic("implement me")
+	routeInfos := g.RouteGroup.Any(path, handler, middleware...)
+	for i, routeInfo := range routeInfos {
+		routeInfos[i] = g.register(routeInfo)
+	}
+	return routeInfos
 }
 
 func (g *routeInfoGroup) Match(methods []string, path string, handler HandlerFunc, middleware ...MiddlewareFunc) []RouteInfo {
-	//TODO implement me
-	panic("implement me")
+	routeInfos := g.RouteGroup.Match(methods, path, handler, middleware...)
+	for i, routeInfo := range routeInfos {
+		routeInfos[i] = g.register(routeInfo)
+	}
+	return routeInfos
+}
+
+func (g *routeInfoGroup) Static(prefix string, fsys fs.FS, options ...StaticOption) []RouteInfo {
+	routeInfos := g.RouteGroup.Static(prefix, fsys, options...)
+	for i, routeInfo := range routeInfos {
+		routeInfos[i] = g.register(routeInfo)
+	}
+	return routeInfos
+}
+
+func (g *routeInfoGroup) RouteNotFound(path string, h HandlerFunc, m ...MiddlewareFunc) RouteInfo {
+	routeInfo := g.RouteGroup.RouteNotFound(path, h, m...)
+	return g.register(routeInfo)
+}
+
+func (g *routeInfoGroup) With(matchers ...RouteMatcher) RouteGroup {
+	return &routeInfoGroup{
+		app:        g.app,
+		RouteGroup: g.RouteGroup.With(matchers...),
+	}
 }
 
 func (g *routeInfoGroup) Group(prefix string, middleware ...MiddlewareFunc) RouteGroup {
 	rg := g.RouteGroup.Group(prefix, middleware...)
 	return &routeInfoGroup{
-		routes:     g.routes,
+		app:        g.app,
 		RouteGroup: rg,
 	}
 }
 
 func (g *routeInfoGroup) Add(method, path string, handler HandlerFunc, middleware ...MiddlewareFunc) RouteInfo {
 	routeInfo := g.RouteGroup.Add(method, path, handler, middleware...)
-	g.routes[routeInfo.Name()] = routeInfo
-
-	////keep track of the amount of params is the biggest route, used for context optimisation
-	//numParams := len(routeInfo.Params())
-	//if numParams > g.maxParams {
-	//	g.maxParams = numParams
-	//}
-
-	return routeInfo
+	return g.register(routeInfo)
 }
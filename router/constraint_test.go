@@ -0,0 +1,113 @@
+package router
+
+import (
+	"github.com/mbict/webapp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_router_param_constraints(t *testing.T) {
+	r := New()
+	reply := func(name string) webapp.HandlerFunc {
+		return func(c webapp.Context) error {
+			return c.String(http.StatusOK, name+":"+c.Param(c.ParamNames()[len(c.ParamNames())-1]))
+		}
+	}
+	r.GET("/users/{id:int}", reply("int"))
+	r.GET("/users/{id:uuid}", reply("uuid"))
+	r.GET("/users/{name}", reply("name"))
+	r.GET("/users/{id:int}/posts", reply("posts"))
+	r.GET("/tags/{slug:[a-z-]+}", reply("slug"))
+	r.GET("/codes/{code:[0-9]{3}}", reply("code"))
+	r.GET("/posts/{page:int?}", reply("page"))
+
+	tests := map[string]struct {
+		path string
+		code int
+		body string
+	}{
+		"int":                    {path: "/users/12", code: 200, body: "int:12"},
+		"negative int":           {path: "/users/-12", code: 200, body: "int:-12"},
+		"uuid":                   {path: "/users/6ba7b810-9dad-11d1-80b4-00c04fd430c8", code: 200, body: "uuid:6ba7b810-9dad-11d1-80b4-00c04fd430c8"},
+		"falls through":          {path: "/users/john", code: 200, body: "name:john"},
+		"deeper":                 {path: "/users/12/posts", code: 200, body: "posts:12"},
+		"deeper mismatch":        {path: "/users/john/posts", code: 404},
+		"regex":                  {path: "/tags/go-lang", code: 200, body: "slug:go-lang"},
+		"regex mismatch":         {path: "/tags/Go", code: 404},
+		"regex with braces":      {path: "/codes/404", code: 200, body: "code:404"},
+		"regex with braces miss": {path: "/codes/4044", code: 404},
+		"optional":               {path: "/posts/2", code: 200, body: "page:2"},
+		"optional omitted":       {path: "/posts", code: 200, body: "page:"},
+		"optional mismatch":      {path: "/posts/last", code: 404},
+	}
+
+	app := webapp.New(webapp.WithRouter(r))
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, test.path, nil)
+			rw := httptest.NewRecorder()
+			app.ServeHTTP(rw, req)
+
+			assert.Equal(t, test.code, rw.Code)
+			if test.code == 200 {
+				assert.Equal(t, test.body, rw.Body.String())
+			}
+		})
+	}
+}
+
+func Test_router_param_constraint_specs(t *testing.T) {
+	r := New()
+	routeInfo := r.GET("/users/{id:int}/posts/{page:int?}", func(c webapp.Context) error { return nil })
+
+	assert.Equal(t, []string{"id", "page"}, routeInfo.Params())
+	specs := routeInfo.ParamSpecs()
+	require.Len(t, specs, 2)
+	assert.Equal(t, "id", specs[0].Name)
+	assert.Equal(t, "int", specs[0].Constraint)
+	assert.False(t, specs[0].Optional)
+	assert.True(t, specs[1].Optional)
+
+	h, _, params := r.Lookup(http.MethodGet, "/users/1/posts")
+	require.NotNil(t, h)
+	assert.Equal(t, []string{"1"}, params)
+}
+
+func Test_router_param_constraint_conflicts(t *testing.T) {
+	tests := map[string][]string{
+		"same constraint":            {"/users/{id:int}", "/users/{nr:int}"},
+		"two without constraint":     {"/users/{id}", "/users/{name}"},
+		"optional not last":          {"/users/{id:int?}/posts"},
+		"invalid regular expression": {"/users/{id:[0-9}"},
+	}
+
+	for name, paths := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Panics(t, func() {
+				r := New()
+				for _, path := range paths {
+					r.GET(path, func(c webapp.Context) error { return nil })
+				}
+			})
+		})
+	}
+}
+
+func Test_router_param_constraints_match_before_unconstrained(t *testing.T) {
+	r := New()
+	r.GET("/users/{name}/edit", func(c webapp.Context) error { return nil })
+	r.GET("/users/{id:int}/edit", func(c webapp.Context) error { return nil })
+
+	_, routeInfo, params := r.Lookup(http.MethodGet, "/users/12/edit")
+	require.NotNil(t, routeInfo)
+	assert.Equal(t, "/users/{id:int}/edit", routeInfo.Path())
+	assert.Equal(t, []string{"12"}, params)
+
+	_, routeInfo, params = r.Lookup(http.MethodGet, "/users/john/edit")
+	require.NotNil(t, routeInfo)
+	assert.Equal(t, "/users/{name}/edit", routeInfo.Path())
+	assert.Equal(t, []string{"john"}, params)
+}
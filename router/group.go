@@ -2,15 +2,31 @@ package router
 
 import (
 	"github.com/mbict/webapp"
+	"io/fs"
 	"net/http"
+	"strings"
 )
 
 type group struct {
 	prefix     string
 	middleware []webapp.MiddlewareFunc
+	matchers   []webapp.RouteMatcher
 	router     *Router
 }
 
+func (g *group) RouteNotFound(path string, h webapp.HandlerFunc, m ...webapp.MiddlewareFunc) webapp.RouteInfo {
+	middleware := make([]webapp.MiddlewareFunc, 0, len(g.middleware)+len(m))
+	middleware = append(middleware, g.middleware...)
+	middleware = append(middleware, m...)
+
+	path = g.prefix + path
+	return g.router.notFoundRoutes.Add(path, func(params []string) webapp.RouteInfo {
+		route := newRouteInfo(webapp.RouteNotFoundMethod, path, h)
+		route.params = params
+		return route
+	}, h, middleware...)
+}
+
 func (g *group) Use(middleware ...webapp.MiddlewareFunc) {
 	g.middleware = append(g.middleware, middleware...)
 }
@@ -52,13 +68,32 @@ func (g *group) TRACE(path string, h webapp.HandlerFunc, m ...webapp.MiddlewareF
 }
 
 func (g *group) Any(path string, handler webapp.HandlerFunc, middleware ...webapp.MiddlewareFunc) []webapp.RouteInfo {
-	//TODO implement me
-	panic("implement me")
+	return g.Match(webapp.Methods, path, handler, middleware...)
 }
 
 func (g *group) Match(methods []string, path string, handler webapp.HandlerFunc, middleware ...webapp.MiddlewareFunc) []webapp.RouteInfo {
-	//TODO implement me
-	panic("implement me")
+	m := make([]webapp.MiddlewareFunc, 0, len(g.middleware)+len(middleware))
+	m = append(m, g.middleware...)
+	m = append(m, middleware...)
+
+	routes := make([]webapp.RouteInfo, 0, len(methods))
+	for _, method := range methods {
+		route := g.router.add(method, g.prefix+path, handler, g.matchers, m...).(*routeInfo)
+		route.name = webapp.MethodQualifiedName(route.name, method)
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+func (g *group) Static(prefix string, fsys fs.FS, options ...webapp.StaticOption) []webapp.RouteInfo {
+	handler := webapp.StaticHandler(fsys, options...)
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	var routes []webapp.RouteInfo
+	if prefix != "" {
+		routes = g.Match(webapp.StaticMethods, prefix, handler)
+	}
+	return append(routes, g.Match(webapp.StaticMethods, prefix+"/*filepath", handler)...)
 }
 
 func (g *group) Group(prefix string, middleware ...webapp.MiddlewareFunc) webapp.RouteGroup {
@@ -69,6 +104,16 @@ func (g *group) Group(prefix string, middleware ...webapp.MiddlewareFunc) webapp
 	return &group{
 		prefix:     g.prefix + prefix,
 		middleware: m,
+		matchers:   g.matchers,
+		router:     g.router,
+	}
+}
+
+func (g *group) With(matchers ...webapp.RouteMatcher) webapp.RouteGroup {
+	return &group{
+		prefix:     g.prefix,
+		middleware: g.middleware,
+		matchers:   append(g.matchers[:len(g.matchers):len(g.matchers)], matchers...),
 		router:     g.router,
 	}
 }
@@ -78,5 +123,5 @@ func (g *group) Add(method, path string, handler webapp.HandlerFunc, middleware
 	m = append(m, g.middleware...)
 	m = append(m, middleware...)
 
-	return g.router.add(method, g.prefix+path, handler, m...)
+	return g.router.add(method, g.prefix+path, handler, g.matchers, m...)
 }
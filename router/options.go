@@ -11,3 +11,21 @@ func WithNotFoundHandler(h webapp.Handler) Option {
 		r.NotFound = h
 	}
 }
+
+func WithMethodNotAllowedHandler(h webapp.Handler) Option {
+	return func(r *Router) {
+		r.MethodNotAllowed = h
+	}
+}
+
+func WithGlobalOPTIONSHandler(h webapp.Handler) Option {
+	return func(r *Router) {
+		r.GlobalOPTIONS = h
+	}
+}
+
+func WithPanicHandler(h func(webapp.Context, interface{}) error) Option {
+	return func(r *Router) {
+		r.PanicHandler = h
+	}
+}
@@ -0,0 +1,124 @@
+package router
+
+import (
+	"github.com/mbict/webapp"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func serveOptions(app webapp.WebApp, path string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(http.MethodOptions, path, nil)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+	return rw
+}
+
+func Test_router_automatic_options(t *testing.T) {
+	app := webapp.New(webapp.WithRouter(New()))
+	app.GET("/users/{id}", func(c webapp.Context) error { return nil })
+	app.PUT("/users/{id}", func(c webapp.Context) error { return nil })
+	app.POST("/users", func(c webapp.Context) error { return nil })
+
+	rw := serveOptions(app, "/users/12")
+	assert.Equal(t, http.StatusNoContent, rw.Code)
+	assert.Equal(t, "GET, OPTIONS, PUT", rw.Header().Get(webapp.HeaderAllow))
+
+	rw = serveOptions(app, "*")
+	assert.Equal(t, http.StatusNoContent, rw.Code)
+	assert.Equal(t, "GET, OPTIONS, POST, PUT", rw.Header().Get(webapp.HeaderAllow))
+
+	rw = serveOptions(app, "/unknown")
+	assert.Equal(t, http.StatusNotFound, rw.Code)
+}
+
+func Test_router_route_options_handler_takes_priority(t *testing.T) {
+	app := webapp.New(webapp.WithRouter(New(WithGlobalOPTIONSHandler(webapp.HandlerFunc(func(c webapp.Context) error {
+		assert.Fail(t, "global options handler should not be called")
+		return nil
+	})))))
+	app.GET("/users", func(c webapp.Context) error { return nil })
+	app.OPTIONS("/users", func(c webapp.Context) error { return c.String(http.StatusOK, "route options") })
+
+	rw := serveOptions(app, "/users")
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, "route options", rw.Body.String())
+}
+
+func Test_router_global_options_handler(t *testing.T) {
+	cors := webapp.HandlerFunc(func(c webapp.Context) error {
+		c.Response().Header().Set(webapp.HeaderAccessControlAllowOrigin, "*")
+		c.Response().Header().Set(webapp.HeaderAccessControlAllowMethods, c.Response().Header().Get(webapp.HeaderAllow))
+		return c.NoContent()
+	})
+
+	app := webapp.New(webapp.WithRouter(New(WithGlobalOPTIONSHandler(cors))))
+	app.GET("/users", func(c webapp.Context) error { return nil })
+	app.DELETE("/users", func(c webapp.Context) error { return nil })
+
+	rw := serveOptions(app, "/users")
+	assert.Equal(t, http.StatusNoContent, rw.Code)
+	assert.Equal(t, "*", rw.Header().Get(webapp.HeaderAccessControlAllowOrigin))
+	assert.Equal(t, "DELETE, GET, OPTIONS", rw.Header().Get(webapp.HeaderAccessControlAllowMethods))
+}
+
+func Test_router_automatic_options_disabled(t *testing.T) {
+	r := New()
+	r.HandleOPTIONS = false
+
+	app := webapp.New(webapp.WithRouter(r))
+	app.GET("/users", func(c webapp.Context) error { return nil })
+
+	rw := serveOptions(app, "/users")
+	assert.Equal(t, http.StatusMethodNotAllowed, rw.Code)
+	assert.Equal(t, "GET, OPTIONS", rw.Header().Get(webapp.HeaderAllow))
+}
+
+func Test_router_method_not_allowed(t *testing.T) {
+	app := webapp.New(webapp.WithRouter(New()))
+	app.GET("/users", func(c webapp.Context) error { return nil })
+
+	req, _ := http.NewRequest(http.MethodPost, "/users", nil)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rw.Code)
+	assert.Equal(t, "GET, OPTIONS", rw.Header().Get(webapp.HeaderAllow))
+}
+
+func Test_router_method_not_allowed_handler(t *testing.T) {
+	methodNotAllowed := webapp.HandlerFunc(func(c webapp.Context) error {
+		return c.JSON(http.StatusMethodNotAllowed, map[string]interface{}{"allowed": AllowedMethods(c)})
+	})
+
+	app := webapp.New(webapp.WithRouter(New(WithMethodNotAllowedHandler(methodNotAllowed))))
+	app.GET("/users", func(c webapp.Context) error { return nil })
+	app.DELETE("/users", func(c webapp.Context) error { return nil })
+
+	req, _ := http.NewRequest(http.MethodPost, "/users", nil)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rw.Code)
+	assert.Equal(t, "DELETE, GET, OPTIONS", rw.Header().Get(webapp.HeaderAllow))
+	assert.JSONEq(t, `{"allowed":["DELETE","GET","OPTIONS"]}`, rw.Body.String())
+}
+
+func Test_router_method_not_allowed_disabled(t *testing.T) {
+	r := New(WithMethodNotAllowedHandler(webapp.HandlerFunc(func(c webapp.Context) error {
+		assert.Fail(t, "method not allowed handler should not be called")
+		return nil
+	})))
+	r.HandleMethodNotAllowed = false
+
+	app := webapp.New(webapp.WithRouter(r))
+	app.GET("/users", func(c webapp.Context) error { return nil })
+
+	req, _ := http.NewRequest(http.MethodPost, "/users", nil)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusNotFound, rw.Code)
+	assert.Empty(t, rw.Header().Get(webapp.HeaderAllow))
+}
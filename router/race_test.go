@@ -0,0 +1,6 @@
+//go:build race
+
+package router
+
+// raceEnabled is set when the tests run with the race detector, which makes sync.Pool drop items at random
+const raceEnabled = true
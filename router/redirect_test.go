@@ -0,0 +1,90 @@
+package router
+
+import (
+	"github.com/mbict/webapp"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_router_redirects(t *testing.T) {
+	tests := map[string]struct {
+		trailingSlash bool
+		fixedPath     bool
+		method        string
+		path          string
+		code          int
+		location      string
+	}{
+		"trailing slash removed for GET": {
+			trailingSlash: true, method: http.MethodGet, path: "/users/", code: http.StatusMovedPermanently, location: "/users",
+		},
+		"trailing slash added for GET": {
+			trailingSlash: true, method: http.MethodGet, path: "/posts", code: http.StatusMovedPermanently, location: "/posts/",
+		},
+		"trailing slash removed for POST": {
+			trailingSlash: true, method: http.MethodPost, path: "/users/", code: http.StatusPermanentRedirect, location: "/users",
+		},
+		"trailing slash keeps query string": {
+			trailingSlash: true, method: http.MethodGet, path: "/users/?page=2&sort=name", code: http.StatusMovedPermanently, location: "/users?page=2&sort=name",
+		},
+		"trailing slash with params": {
+			trailingSlash: true, method: http.MethodGet, path: "/users/12/", code: http.StatusMovedPermanently, location: "/users/12",
+		},
+		"trailing slash disabled": {
+			trailingSlash: false, method: http.MethodGet, path: "/users/", code: http.StatusNotFound,
+		},
+		"fixed path case insensitive for GET": {
+			fixedPath: true, method: http.MethodGet, path: "/USERS", code: http.StatusMovedPermanently, location: "/users",
+		},
+		"fixed path case insensitive for PUT": {
+			fixedPath: true, method: http.MethodPut, path: "/Users/12", code: http.StatusPermanentRedirect, location: "/users/12",
+		},
+		"fixed path cleaned": {
+			fixedPath: true, method: http.MethodGet, path: "/foo/..//users", code: http.StatusMovedPermanently, location: "/users",
+		},
+		"fixed path keeps query string": {
+			fixedPath: true, method: http.MethodGet, path: "/USERS?page=2", code: http.StatusMovedPermanently, location: "/users?page=2",
+		},
+		"fixed path with trailing slash": {
+			trailingSlash: true, fixedPath: true, method: http.MethodGet, path: "/USERS/", code: http.StatusMovedPermanently, location: "/users",
+		},
+		"fixed path without trailing slash fix": {
+			trailingSlash: false, fixedPath: true, method: http.MethodGet, path: "/USERS/", code: http.StatusNotFound,
+		},
+		"fixed path disabled": {
+			fixedPath: false, method: http.MethodGet, path: "/USERS", code: http.StatusNotFound,
+		},
+		"no redirect for CONNECT": {
+			trailingSlash: true, fixedPath: true, method: http.MethodConnect, path: "/users/", code: http.StatusNotFound,
+		},
+		"no redirect on match": {
+			trailingSlash: true, fixedPath: true, method: http.MethodGet, path: "/users", code: http.StatusNoContent,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := New()
+			r.RedirectTrailingSlash = test.trailingSlash
+			r.RedirectFixedPath = test.fixedPath
+
+			handler := func(c webapp.Context) error { return c.NoContent() }
+			for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodConnect} {
+				r.Add(method, "/users", handler)
+				r.Add(method, "/users/{id}", handler)
+				r.Add(method, "/posts/", handler)
+			}
+
+			app := webapp.New(webapp.WithRouter(r))
+
+			req, _ := http.NewRequest(test.method, test.path, nil)
+			rw := httptest.NewRecorder()
+			app.ServeHTTP(rw, req)
+
+			assert.Equal(t, test.code, rw.Code)
+			assert.Equal(t, test.location, rw.Header().Get(webapp.HeaderLocation))
+		})
+	}
+}
@@ -1,16 +1,15 @@
 package router
 
 import (
-	"fmt"
 	"github.com/mbict/webapp"
+	"net/http"
 	"reflect"
 	"runtime"
+	"sort"
 )
 
 func newRouteInfo(method, path string, handler webapp.HandlerFunc, middleware ...webapp.MiddlewareFunc) *routeInfo {
-	template, params := parsePath(path)
-
-	//! TODO the params count is going to be part of the preallocation in webapp context
+	template := webapp.ParsePathTemplate(path)
 
 	return &routeInfo{
 		name:     handlerName(handler),
@@ -18,7 +17,7 @@ func newRouteInfo(method, path string, handler webapp.HandlerFunc, middleware ..
 		method:   method,
 		path:     path,
 		template: template,
-		params:   params,
+		params:   template.Params(),
 	}
 }
 
@@ -27,8 +26,62 @@ type routeInfo struct {
 	handler  webapp.HandlerFunc
 	method   string
 	path     string
-	template string
+	template *webapp.PathTemplate
 	params   []string
+	meta     webapp.RouteMeta
+
+	// matchers the request must satisfy, routes with the same method and path are candidates of the first
+	// registered route
+	matchers   []webapp.RouteMatcher
+	candidates []*routeInfo
+	vary       string
+}
+
+// addCandidate adds a route with the same path but other matchers, the candidates are ordered by the number of
+// matchers so the most specific route is selected first. It panics when both routes have the same matchers.
+// A route with an optional param is added to the node of every variant, so the candidate is only added once.
+func (r *routeInfo) addCandidate(route *routeInfo) {
+	if r.candidates == nil {
+		r.candidates = []*routeInfo{r}
+	}
+	for _, candidate := range r.candidates {
+		if candidate == route {
+			return
+		}
+		if webapp.SameMatchers(candidate.matchers, route.matchers) {
+			panic("a handle is already registered for path '" + route.path + "'")
+		}
+	}
+
+	r.candidates = append(r.candidates, route)
+	sort.SliceStable(r.candidates, func(i, j int) bool {
+		return len(r.candidates[i].matchers) > len(r.candidates[j].matchers)
+	})
+
+	var matchers []webapp.RouteMatcher
+	for _, candidate := range r.candidates {
+		matchers = append(matchers, candidate.matchers...)
+	}
+	vary := webapp.VaryHeaders(matchers)
+	for _, candidate := range r.candidates {
+		candidate.vary = vary
+	}
+}
+
+// selectRoute returns the most specific candidate matching the request
+func (r *routeInfo) selectRoute(req *http.Request) *routeInfo {
+	if r.candidates == nil {
+		if webapp.MatchAll(r.matchers, req) {
+			return r
+		}
+		return nil
+	}
+	for _, candidate := range r.candidates {
+		if webapp.MatchAll(candidate.matchers, req) {
+			return candidate
+		}
+	}
+	return nil
 }
 
 func (r *routeInfo) Name() string {
@@ -47,17 +100,40 @@ func (r *routeInfo) Params() []string {
 	return r.params
 }
 
-func (r *routeInfo) Reverse(params ...interface{}) string {
-	return fmt.Sprintf(r.template, params...)
+func (r *routeInfo) ParamSpecs() []webapp.ParamSpec {
+	return r.template.ParamSpecs()
 }
 
-func (r *routeInfo) Handler() webapp.HandlerFunc {
-	return r.handler
+func (r *routeInfo) Reverse(params ...interface{}) (string, error) {
+	return r.template.Expand(params...)
+}
+
+func (r *routeInfo) Named(name string) webapp.RouteInfo {
+	r.name = name
+	return r
+}
+
+func (r *routeInfo) Tag(tags ...string) webapp.RouteInfo {
+	r.meta.Tags = append(r.meta.Tags, tags...)
+	return r
+}
+
+func (r *routeInfo) Describe(summary string) webapp.RouteInfo {
+	r.meta.Summary = summary
+	return r
+}
+
+func (r *routeInfo) Deprecate() webapp.RouteInfo {
+	r.meta.Deprecated = true
+	return r
 }
 
-func parsePath(path string) (string, []string) {
-	// TODO implement me getting the param names
-	return "", extractParamNames(path)
+func (r *routeInfo) Meta() webapp.RouteMeta {
+	return r.meta
+}
+
+func (r *routeInfo) Handler() webapp.HandlerFunc {
+	return r.handler
 }
 
 func handlerName(h webapp.HandlerFunc) string {
@@ -2,7 +2,9 @@ package router
 
 import (
 	"github.com/mbict/webapp"
+	"io/fs"
 	"net/http"
+	"sort"
 	"strings"
 )
 
@@ -72,6 +74,19 @@ import (
 //   /files/templates/article.html       match: filepath="/templates/article.html"
 //   /files                              no match, but the router would redirect
 //
+// Parameters can be constrained with int, uuid or a regular expression. When the
+// value does not satisfy the constraint the next parameter at the same position
+// is tried, a parameter without a constraint is tried last. The last parameter
+// of the path can be optional.
+//  Paths: /users/{id:int}, /users/{name}, /posts/{page:int?}
+//
+//  Requests:
+//   /users/12                           match: id="12"
+//   /users/john                         match: name="john"
+//   /posts                              match: page=""
+//   /posts/2                            match: page="2"
+//   /posts/last                         no match
+//
 // The value of parameters is saved as a slice of the Param struct, consisting
 // each of a key and a value. The slice is passed to the Handle func as a third
 // parameter.
@@ -110,6 +125,16 @@ type Handle = webapp.HandlerFunc
 //	return ""
 //}
 
+// AllowedMethodsKey is the context key where the allowed methods are stored for the MethodNotAllowed handler.
+const AllowedMethodsKey = "router.allowed_methods"
+
+// AllowedMethods returns the methods that are allowed for the requested path. It is only available in the
+// MethodNotAllowed handler.
+func AllowedMethods(c webapp.Context) []string {
+	allowed, _ := c.Get(AllowedMethodsKey).([]string)
+	return allowed
+}
+
 // Router is a http.Handler which can be used to dispatch requests to different
 // handler functions via configurable routes
 type Router struct {
@@ -151,58 +176,80 @@ type Router struct {
 	// Custom OPTIONS handlers take priority over automatic replies.
 	HandleOPTIONS bool
 
-	// An optional http.Handler that is called on automatic OPTIONS requests.
+	// An optional handler that is called on automatic OPTIONS requests.
 	// The handler is only called if HandleOPTIONS is true and no OPTIONS
 	// handler for the specific path was set.
-	// The "Allowed" header is set before calling the handler.
-	GlobalOPTIONS http.Handler
+	// The "Allow" header is set before calling the handler.
+	// If it is not set, a 204 No Content response is sent.
+	GlobalOPTIONS webapp.Handler
 
 	// Cached value of global (*) allowed methods
 	globalAllowed string
 
+	// Routes registered with RouteNotFound, ordered by the longest prefix
+	notFoundRoutes webapp.NotFoundRoutes
+
 	// Configurable http.Handler which is called when no matching route is
 	// found. If it is not set, http.NotFound is used.
 	NotFound webapp.Handler
 
-	// Configurable http.Handler which is called when a request
+	// Configurable handler which is called when a request
 	// cannot be routed and HandleMethodNotAllowed is true.
-	// If it is not set, http.Error with http.StatusMethodNotAllowed is used.
+	// If it is not set, webapp.ErrMethodNotAllowed is returned.
 	// The "Allow" header with allowed request methods is set before the handler
-	// is called.
+	// is called, the allowed methods are available with AllowedMethods.
 	MethodNotAllowed webapp.Handler
 
 	// Function to handle panics recovered from http handlers.
 	// It should be used to generate a error page and return the http error code
 	// 500 (Internal Server Error).
-	// The handler can be used to keep your server from crashing because of
-	// unrecovered panics.
-	PanicHandler func(http.ResponseWriter, *http.Request, interface{})
+	// The returned error is passed to the error handler of the webapp.
+	// If it is not set, the panic is recovered by the webapp, see webapp.WithRecover.
+	PanicHandler func(webapp.Context, interface{}) error
 
 	// Implements the basic router routing methods
 	*group
 }
 
-func (r *Router) RouteNotFound(path string, h webapp.HandlerFunc, m ...webapp.MiddlewareFunc) webapp.RouteInfo {
-	//TODO implement me
-	panic("implement me")
+// Lookup allows the manual lookup of a method + path combo.
+// This is e.g. useful to build a framework around this router.
+// The returned params are the values extracted from the path, in the same order as the params of the route.
+func (r *Router) Lookup(method, path string) (webapp.HandlerFunc, webapp.RouteInfo, []string) {
+	if root := r.trees[method]; root != nil {
+		var ps []string
+		if routeInfo, _ := root.getValue(path, &ps); routeInfo != nil {
+			return routeInfo.handler, routeInfo, ps
+		}
+	}
+	return nil, nil, nil
 }
 
-func (r *Router) Lookup(method, path string) (webapp.HandlerFunc, webapp.RouteInfo) {
-	/*if root := r.trees[method]; root != nil {
-		handler, ps, tsr := root.getValue(path, r.getParams)
-		if handler == nil {
-			r.putParams(ps)
-			return nil, nil, tsr
-		}
-		if ps == nil {
-			return handler, nil, tsr
+// Walk calls fn for every registered route, ordered by method. The walk stops when fn returns an error, the error
+// is returned by Walk.
+func (r *Router) Walk(fn func(webapp.RouteInfo) error) error {
+	methods := make([]string, 0, len(r.trees))
+	for method := range r.trees {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	seen := make(map[*routeInfo]struct{})
+	for _, method := range methods {
+		if err := r.trees[method].walk(fn, seen); err != nil {
+			return err
 		}
-		return handler, *ps, tsr
-	}*/
-	return nil, nil
+	}
+	return nil
 }
 
-func (r *Router) Handle(c webapp.Context) error {
+func (r *Router) Handle(c webapp.Context) (err error) {
+	if r.PanicHandler != nil {
+		defer func() {
+			if rcv := recover(); rcv != nil {
+				err = r.PanicHandler(c, rcv)
+			}
+		}()
+	}
 
 	path := c.Path()
 	if root := r.trees[c.Method()]; root != nil {
@@ -212,12 +259,19 @@ func (r *Router) Handle(c webapp.Context) error {
 		ps := pc.ParamValuesPtr()
 		*ps = (*ps)[0:0] // reset slice
 
-		if routeInfo, _ := root.getValue(path, ps); routeInfo != nil {
-			pc.SetParamNames(routeInfo.Params()...)
+		if routeInfo, tsr := root.getValue(path, ps); routeInfo != nil {
+			if routeInfo.vary != "" {
+				c.Response().Header().Add(webapp.HeaderVary, routeInfo.vary)
+			}
 
-			return routeInfo.handler(c)
+			// the route is skipped when none of the candidates matches the request
+			if routeInfo = routeInfo.selectRoute(c.Request()); routeInfo != nil {
+				pc.SetParamNames(routeInfo.Params()...)
+				pc.SetCurrentRoute(routeInfo)
 
-		} /*else if c.Method() != http.MethodConnect && path != "/" {
+				return routeInfo.handler(c)
+			}
+		} else if c.Method() != http.MethodConnect && path != "/" {
 			// Moved Permanently, request with GET method
 			code := http.StatusMovedPermanently
 			if c.Method() != http.MethodGet {
@@ -227,12 +281,9 @@ func (r *Router) Handle(c webapp.Context) error {
 
 			if tsr && r.RedirectTrailingSlash {
 				if len(path) > 1 && path[len(path)-1] == '/' {
-					req.URL.Path = path[:len(path)-1]
-				} else {
-					req.URL.Path = path + "/"
+					return redirect(c, code, path[:len(path)-1])
 				}
-				http.Redirect(w, req, req.URL.String(), code)
-				return
+				return redirect(c, code, path+"/")
 			}
 
 			// Try to fix the request path
@@ -242,37 +293,57 @@ func (r *Router) Handle(c webapp.Context) error {
 					r.RedirectTrailingSlash,
 				)
 				if found {
-					req.URL.Path = fixedPath
-					http.Redirect(w, req, req.URL.String(), code)
-					return
+					return redirect(c, code, fixedPath)
 				}
 			}
-		}*/
+		}
 	}
 
 	if c.Method() == http.MethodOptions && r.HandleOPTIONS {
 		// Handle OPTIONS requests
 		if allow := r.allowed(path, http.MethodOptions); allow != "" {
-			c.Response().Header().Set("Allow", allow)
+			c.Response().Header().Set(webapp.HeaderAllow, allow)
 
-			//todo determine what todo here
 			if r.GlobalOPTIONS != nil {
-				//r.GlobalOPTIONS.ServeHTTP(w, req)
+				return r.GlobalOPTIONS.Handle(c)
 			}
-			return nil
+			return c.NoContent()
 		}
-	} else { //Handle method not allowed
+	} else if r.HandleMethodNotAllowed { //Handle method not allowed
 		if allow := r.allowed(path, c.Method()); allow != "" {
-			c.Response().Header().Set("Allow", allow)
+			c.Response().Header().Set(webapp.HeaderAllow, allow)
+
+			if r.MethodNotAllowed != nil {
+				c.Set(AllowedMethodsKey, strings.Split(allow, ", "))
+				return r.MethodNotAllowed.Handle(c)
+			}
 			return webapp.ErrMethodNotAllowed
 		}
 	}
 
 	// Handle 404
+	if handler, route, values := r.notFoundRoutes.Find(path); handler != nil {
+		pc := c.(webapp.ParamsContext)
+		ps := pc.ParamValuesPtr()
+		*ps = append((*ps)[0:0], values...)
+		pc.SetParamNames(route.Params()...)
+		pc.SetCurrentRoute(route)
+
+		return handler(c)
+	}
 	return r.NotFound.Handle(c)
 }
 
-func (r *Router) add(method, path string, handler webapp.HandlerFunc, middleware ...webapp.MiddlewareFunc) webapp.RouteInfo {
+// redirect redirects the request to the path, the query string of the request is kept and the base path of a mounted
+// webapp is added
+func redirect(c webapp.Context, code int, path string) error {
+	u := *c.Request().URL
+	u.Path = webapp.BasePath(c.Request()) + path
+	u.RawPath = ""
+	return c.Redirect(code, u.String())
+}
+
+func (r *Router) add(method, path string, handler webapp.HandlerFunc, matchers []webapp.RouteMatcher, middleware ...webapp.MiddlewareFunc) webapp.RouteInfo {
 
 	if method == "" {
 		panic("method must not be empty")
@@ -297,16 +368,13 @@ func (r *Router) add(method, path string, handler webapp.HandlerFunc, middleware
 	}
 
 	routeInfo := newRouteInfo(method, path, handler, middleware...)
-	root.addRoute(path, routeInfo)
-
-	// Lazy-init paramsPool alloc func
-	/* todo move this into the webapp while registering routes, and modify the context creation flow
-	if r.paramsPool.New == nil && r.maxParams > 0 {
-		r.paramsPool.New = func() interface{} {
-			ps := make(Params, 0, r.maxParams)
-			return &ps
-		}
-	}*/
+	routeInfo.matchers = matchers
+	routeInfo.vary = webapp.VaryHeaders(matchers)
+
+	// a path with an optional param is registered with and without the param
+	for _, variant := range routeInfo.template.Variants() {
+		root.addRoute(variant, routeInfo)
+	}
 
 	return routeInfo
 }
@@ -361,24 +429,19 @@ func New(options ...Option) *Router {
 // path /defined/root/dir/*filepath.
 // For example if root is "/etc" and *filepath is "passwd", the local file
 // "/etc/passwd" would be served.
-// Internally a http.FileServer is used, therefore http.NotFound is used instead
-// of the Router's NotFound handler.
+// The files are served with webapp.StaticHandler, a file that does not exist
+// results in a webapp.ErrNotFound error.
 // To use the operating system's file system implementation,
-// use http.Dir:
+// use os.DirFS:
 //
-//	router.ServeFiles("/src/*filepath", http.Dir("/var/www"))
-/*func (r *Router) ServeFiles(path string, root http.FileSystem) {
+//	router.ServeFiles("/src/*filepath", os.DirFS("/var/www"))
+func (r *Router) ServeFiles(path string, root fs.FS) []webapp.RouteInfo {
 	if len(path) < 10 || path[len(path)-10:] != "/*filepath" {
 		panic("path must end with /*filepath in path '" + path + "'")
 	}
 
-	fileServer := http.FileServer(root)
-
-	r.GET(path, func(w http.ResponseWriter, req *http.Request, ps Params) {
-		req.URL.Path = ps.ByName("filepath")
-		fileServer.ServeHTTP(w, req)
-	})
-}*/
+	return r.Match(webapp.StaticMethods, path, webapp.StaticHandler(root))
+}
 
 func (r *Router) allowed(path, reqMethod string) (allow string) {
 	allowed := make([]string, 0, 9)
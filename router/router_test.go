@@ -1,12 +1,16 @@
 package router
 
 import (
+	"errors"
 	"github.com/mbict/webapp"
 	"github.com/mbict/webapp/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/url"
+	"testing"
 )
 
 type testContext struct {
@@ -293,3 +297,74 @@ func (t *testContext) Container() container.Container {
 //		}
 //	}
 //}
+
+func Test_router_lookup(t *testing.T) {
+	r := New()
+	r.GET("/users/{id}/posts/{post}", func(c webapp.Context) error { return nil })
+	r.GET("/files/*filepath", func(c webapp.Context) error { return nil })
+
+	h, routeInfo, params := r.Lookup(http.MethodGet, "/users/12/posts/34")
+	require.NotNil(t, h)
+	assert.Equal(t, "/users/{id}/posts/{post}", routeInfo.Path())
+	assert.Equal(t, []string{"id", "post"}, routeInfo.Params())
+	assert.Equal(t, []string{"12", "34"}, params)
+
+	h, routeInfo, params = r.Lookup(http.MethodGet, "/files/css/app.css")
+	require.NotNil(t, h)
+	assert.Equal(t, "/files/*filepath", routeInfo.Path())
+	assert.Equal(t, []string{"/css/app.css"}, params)
+
+	uri, err := routeInfo.Reverse(params[0])
+	require.NoError(t, err)
+	assert.Equal(t, "/files/css/app.css", uri)
+
+	h, routeInfo, params = r.Lookup(http.MethodPost, "/users/12/posts/34")
+	assert.Nil(t, h)
+	assert.Nil(t, routeInfo)
+	assert.Nil(t, params)
+
+	h, routeInfo, params = r.Lookup(http.MethodGet, "/unknown")
+	assert.Nil(t, h)
+	assert.Nil(t, routeInfo)
+	assert.Nil(t, params)
+}
+
+func Test_router_walk(t *testing.T) {
+	r := New()
+	r.POST("/users", func(c webapp.Context) error { return nil })
+	r.GET("/users", func(c webapp.Context) error { return nil })
+	r.GET("/users/{id}", func(c webapp.Context) error { return nil })
+	r.Group("/admin").DELETE("/users/{id}", func(c webapp.Context) error { return nil })
+	r.GET("/posts/{page:int?}", func(c webapp.Context) error { return nil })
+
+	var routes []string
+	err := r.Walk(func(routeInfo webapp.RouteInfo) error {
+		routes = append(routes, routeInfo.Method()+" "+routeInfo.Path())
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"DELETE /admin/users/{id}",
+		"GET /users",
+		"GET /users/{id}",
+		"GET /posts/{page:int?}",
+		"POST /users",
+	}, routes)
+}
+
+func Test_router_walk_stops_on_error(t *testing.T) {
+	r := New()
+	r.GET("/foo", func(c webapp.Context) error { return nil })
+	r.GET("/bar", func(c webapp.Context) error { return nil })
+
+	stop := errors.New("stop")
+	calls := 0
+	err := r.Walk(func(routeInfo webapp.RouteInfo) error {
+		calls++
+		return stop
+	})
+
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+}
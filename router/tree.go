@@ -5,6 +5,7 @@ package router
 // in the LICENSE file.
 
 import (
+	"github.com/mbict/webapp"
 	"strings"
 	"unicode"
 	"unicode/utf8"
@@ -36,21 +37,30 @@ func findWildcard(path string) (wildcard string, i int, valid bool) {
 			continue
 		}
 
-		isValidParam := c != '{'
+		// Skip the param up to the closing brace, the constraint can contain braces and closing characters
+		offset := start + 1
+		if c == '{' {
+			end := webapp.ParamEnd(path[start:])
+			if end < 0 {
+				return path[start:], start, false
+			}
+			if spec := webapp.ParseParamSpec(path[start+1 : start+end]); strings.ContainsAny(spec.Name, "{}*/:@#;|") {
+				return path[start : start+end+1], start, false
+			}
+			offset = start + end + 1
+		}
 
 		// Find end and check for invalid characters
 		valid = true
-		for end, c := range []byte(path[start+1:]) {
+		for end, c := range []byte(path[offset:]) {
 			switch {
-			case c == '}' && !isValidParam:
-				isValidParam = true
 			case isClosingCharacter(c):
-				return path[start : start+1+end], start, valid && isValidParam
+				return path[start : offset+end], start, valid
 			case c == '{' || c == '*':
 				valid = false
 			}
 		}
-		return path[start:], start, valid && isValidParam
+		return path[start:], start, valid
 	}
 	return "", -1, false
 }
@@ -99,6 +109,9 @@ type node struct {
 	priority  uint32
 	children  []*node
 	routeInfo *routeInfo
+
+	// constraint the value of a param node must satisfy, nil when the param matches any value
+	constraint func(string) bool
 }
 
 // Increments priority of the given child and reorders if necessary
@@ -168,38 +181,37 @@ walk:
 			path = path[i:]
 
 			if n.wildChild {
-				n = n.children[0]
-				n.priority++
-
-				// Check if the wildcard matches
-				if len(path) >= len(n.path) && n.path == path[:len(n.path)] &&
-					// Adding a child to a catchAll is not possible
-					n.nType != catchAll &&
-					// Check for longer wildcard, e.g. :name and :names
-					(len(n.path) >= len(path) || isClosingCharacter(path[len(n.path)])) {
+				// Params with a different constraint can share the same position
+				if child := n.wildcardChild(path); child != nil {
+					n = child
+					n.priority++
 					continue walk
-				} else {
-					// Wildcard conflict
-					pathSeg := path
-					if n.nType != catchAll {
-						pathSeg = strings.SplitN(pathSeg, "/", 2)[0]
-					}
-					prefix := fullPath[:strings.Index(fullPath, pathSeg)] + n.path
-
-					if pathSeg[0] == '{' && pathSeg[len(pathSeg)-1] != '}' {
-						panic("param '" + pathSeg +
-							"' in new path '" + fullPath +
-							"' is not properly closed with a '}' and conflicts with existing wildcard '" + n.path +
-							"' in existing prefix '" + prefix +
-							"'")
-					}
+				}
+				if n.addParamChild(path, fullPath, routeInfo) {
+					return
+				}
+
+				// Wildcard conflict
+				n = n.children[0]
+				pathSeg := path
+				if n.nType != catchAll {
+					pathSeg = strings.SplitN(pathSeg, "/", 2)[0]
+				}
+				prefix := fullPath[:strings.Index(fullPath, pathSeg)] + n.path
 
-					panic("'" + pathSeg +
+				if pathSeg[0] == '{' && pathSeg[len(pathSeg)-1] != '}' {
+					panic("param '" + pathSeg +
 						"' in new path '" + fullPath +
-						"' conflicts with existing wildcard '" + n.path +
+						"' is not properly closed with a '}' and conflicts with existing wildcard '" + n.path +
 						"' in existing prefix '" + prefix +
 						"'")
 				}
+
+				panic("'" + pathSeg +
+					"' in new path '" + fullPath +
+					"' conflicts with existing wildcard '" + n.path +
+					"' in existing prefix '" + prefix +
+					"'")
 			}
 
 			idxc := path[0]
@@ -233,9 +245,10 @@ walk:
 			return
 		}
 
-		// Otherwise add handle to current node
+		// Otherwise add handle to current node, a route for the same path with other matchers is added as candidate
 		if n.routeInfo != nil {
-			panic("a handle is already registered for path '" + fullPath + "'")
+			n.routeInfo.addCandidate(routeInfo)
+			return
 		}
 		n.routeInfo = routeInfo
 		return
@@ -282,10 +295,7 @@ func (n *node) insertChild(path, fullPath string, routeInfo *routeInfo) {
 			}
 
 			n.wildChild = true
-			child := &node{
-				nType: param,
-				path:  wildcard,
-			}
+			child := newParamNode(wildcard, fullPath)
 			n.children = []*node{child}
 			n = child
 			n.priority++
@@ -351,6 +361,82 @@ func (n *node) insertChild(path, fullPath string, routeInfo *routeInfo) {
 	n.routeInfo = routeInfo
 }
 
+// newParamNode creates the node for the param wildcard, the constraint of the param is compiled
+func newParamNode(wildcard, fullPath string) *node {
+	spec := webapp.ParseParamSpec(wildcard[1 : len(wildcard)-1])
+	if spec.Name == "" {
+		panic("wildcards must be named with a non-empty name in path '" + fullPath + "'")
+	}
+	if spec.Optional {
+		panic("optional params are only allowed as the last segment of the path in path '" + fullPath + "'")
+	}
+
+	n := &node{
+		nType: param,
+		path:  wildcard,
+	}
+	if spec.Constraint != "" {
+		n.constraint = spec.Match
+	}
+	return n
+}
+
+// wildcardChild returns the param child with the same wildcard as the start of the path
+func (n *node) wildcardChild(path string) *node {
+	for _, child := range n.children {
+		if len(path) >= len(child.path) && child.path == path[:len(child.path)] &&
+			// Adding a child to a catchAll is not possible, the same catch-all can be added as a candidate
+			(child.nType != catchAll || child.path == path) &&
+			// Check for longer wildcard, e.g. :name and :names
+			(len(child.path) >= len(path) || isClosingCharacter(path[len(child.path)])) {
+			return child
+		}
+	}
+	return nil
+}
+
+// addParamChild adds a param with a different constraint next to the existing params. The params with a constraint
+// are matched first, at most one param without a constraint is allowed and it is matched last.
+// It returns false when the param conflicts with the existing params.
+func (n *node) addParamChild(path, fullPath string, routeInfo *routeInfo) bool {
+	if path[0] != '{' || n.children[0].nType != param {
+		return false
+	}
+	wildcard, _, valid := findWildcard(path)
+	if !valid || wildcard[len(wildcard)-1] != '}' {
+		return false
+	}
+
+	child := newParamNode(wildcard, fullPath)
+	constraint := webapp.ParseParamSpec(wildcard[1 : len(wildcard)-1]).Constraint
+	for _, existing := range n.children {
+		if webapp.ParseParamSpec(existing.path[1:len(existing.path)-1]).Constraint == constraint {
+			return false
+		}
+	}
+	child.priority = 1
+
+	if child.constraint != nil {
+		// keep the param without a constraint as last
+		last := len(n.children)
+		if n.children[last-1].constraint == nil {
+			last--
+		}
+		n.children = append(n.children[:last], append([]*node{child}, n.children[last:]...)...)
+	} else {
+		n.children = append(n.children, child)
+	}
+
+	if len(wildcard) < len(path) {
+		rest := &node{priority: 1}
+		child.children = []*node{rest}
+		rest.insertChild(path[len(wildcard):], fullPath, routeInfo)
+		return true
+	}
+	child.routeInfo = routeInfo
+	return true
+}
+
 // isClosingChar are the allowed characters to close a path segment for a path variable
 func isClosingCharacter(c byte) bool {
 	return c == '/' || c == ':' || c == '@' || c == '#' || c == ';' || c == '|'
@@ -389,15 +475,13 @@ walk: // Outer loop for walking the tree
 				}
 
 				// Handle wildcard child
+				if n.children[0].nType == param {
+					return n.getParamValue(path, params)
+				}
+
 				n = n.children[0]
 				switch n.nType {
-				case param:
-					// Find param end (either '/' or ':' or path end)
-					end := 0
-					for end < len(path) && !isClosingCharacter(path[end]) {
-						end++
-					}
-
+				case catchAll:
 					// Save param value
 					if params != nil {
 						i := len(*params)
@@ -411,39 +495,6 @@ walk: // Outer loop for walking the tree
 
 						// Expand slice within preallocated capacity
 						*params = (*params)[:i+1]
-						(*params)[i] = path[:end]
-					}
-
-					// We need to go deeper!
-					if end < len(path) {
-						if len(n.children) > 0 {
-							path = path[end:]
-							n = n.children[0]
-							continue walk
-						}
-
-						// ... but we can't
-						tsr = len(path) == end+1
-						return
-					}
-
-					if routeInfo = n.routeInfo; routeInfo != nil {
-						return
-					} else if len(n.children) == 1 {
-						// No handle found. Check if a handle for this path + a
-						// trailing slash exists for TSR recommendation
-						n = n.children[0]
-						tsr = (n.path == "/" && n.routeInfo != nil) || (n.path == "" && n.indices == "/")
-					}
-
-					return
-
-				case catchAll:
-					// Save param value
-					if params != nil {
-						// Expand slice within preallocated capacity
-						i := len(*params)
-						*params = (*params)[:i+1]
 						(*params)[i] = path
 					}
 
@@ -491,6 +542,111 @@ walk: // Outer loop for walking the tree
 	}
 }
 
+// getParamValue matches the path against the param children of the node. The params are tried in order, a param
+// with a constraint the value does not satisfy, or without a route for the rest of the path, falls through to the
+// next param.
+func (n *node) getParamValue(path string, params *[]string) (routeInfo *routeInfo, tsr bool) {
+	// Find param end (either a closing character or path end)
+	end := 0
+	for end < len(path) && !isClosingCharacter(path[end]) {
+		end++
+	}
+
+	for _, child := range n.children {
+		if child.constraint != nil && !child.constraint(path[:end]) {
+			continue
+		}
+
+		// Save param value
+		saved := 0
+		if params != nil {
+			saved = len(*params)
+
+			//in case the params pre allocated size is too little we expand it
+			if cap(*params) <= saved {
+				dst := make([]string, saved+1)
+				copy(dst, *params)
+				*params = dst
+			}
+
+			// Expand slice within preallocated capacity
+			*params = (*params)[:saved+1]
+			(*params)[saved] = path[:end]
+		}
+
+		var childTsr bool
+		if routeInfo, childTsr = child.getParamChildValue(path, end, params); routeInfo != nil {
+			return routeInfo, false
+		}
+		tsr = tsr || childTsr
+
+		if params != nil {
+			*params = (*params)[:saved]
+		}
+	}
+	return nil, tsr
+}
+
+// getParamChildValue returns the route for the rest of the path after the param value
+func (n *node) getParamChildValue(path string, end int, params *[]string) (routeInfo *routeInfo, tsr bool) {
+	// We need to go deeper!
+	if end < len(path) {
+		if len(n.children) > 0 {
+			return n.children[0].getValue(path[end:], params)
+		}
+
+		// ... but we can't
+		return nil, len(path) == end+1
+	}
+
+	if routeInfo = n.routeInfo; routeInfo != nil {
+		return
+	} else if len(n.children) == 1 {
+		// No handle found. Check if a handle for this path + a
+		// trailing slash exists for TSR recommendation
+		n = n.children[0]
+		tsr = (n.path == "/" && n.routeInfo != nil) || (n.path == "" && n.indices == "/")
+	}
+	return
+}
+
+// paramChildFor returns the first param child with a constraint the value satisfies
+func (n *node) paramChildFor(value string) *node {
+	for _, child := range n.children {
+		if child.constraint == nil || child.constraint(value) {
+			return child
+		}
+	}
+	return nil
+}
+
+// walk calls fn for the route of this node and all the routes of the child nodes, depth first. A route with an
+// optional param is added to the node of every variant, the seen routes are skipped so it is reported once.
+func (n *node) walk(fn func(webapp.RouteInfo) error, seen map[*routeInfo]struct{}) error {
+	if n.routeInfo != nil {
+		routes := n.routeInfo.candidates
+		if routes == nil {
+			routes = []*routeInfo{n.routeInfo}
+		}
+		for _, route := range routes {
+			if _, ok := seen[route]; ok {
+				continue
+			}
+			seen[route] = struct{}{}
+			if err := fn(route); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, child := range n.children {
+		if err := child.walk(fn, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Makes a case-insensitive lookup of the given path and tries to find a handler.
 // It can optionally also fix trailing slashes.
 // It returns the case-corrected path and a bool indicating whether the lookup
@@ -627,6 +783,7 @@ walk: // Outer loop for walking the tree
 				return nil
 			}
 
+			parent := n
 			n = n.children[0]
 			switch n.nType {
 			case param:
@@ -636,6 +793,11 @@ walk: // Outer loop for walking the tree
 					end++
 				}
 
+				// Use the first param with a constraint the value satisfies
+				if n = parent.paramChildFor(path[:end]); n == nil {
+					return nil
+				}
+
 				// Add param value to case-insensitive path
 				ciPath = append(ciPath, path[:end]...)
 
@@ -1,11 +1,14 @@
 package router
 
 import (
+	"fmt"
 	"github.com/mbict/webapp"
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"testing/fstest"
 )
 
 //func Test_webapp_registered_routes(t *testing.T) {
@@ -238,3 +241,386 @@ func BenchmarkSimpleRouteNoAllocations(b *testing.B) {
 		}
 	}
 }
+
+// paramsRoute returns a route path with n params and a request path matching it
+func paramsRoute(n int) (string, string) {
+	route, path := strings.Builder{}, strings.Builder{}
+	route.WriteString("/params")
+	path.WriteString("/params")
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&route, "/{p%d}", i)
+		fmt.Fprintf(&path, "/v%d", i)
+	}
+	return route.String(), path.String()
+}
+
+func Test_webapp_router_params_no_allocations(t *testing.T) {
+	for n := 1; n <= 8; n++ {
+		app := webapp.New(webapp.WithRouter(New()))
+		route, path := paramsRoute(n)
+		var values []string
+		app.GET(route, func(c webapp.Context) error {
+			values = c.ParamValues()
+			return nil
+		})
+
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rw := httptest.NewRecorder()
+		app.ServeHTTP(rw, req)
+		assert.Len(t, values, n)
+
+		if raceEnabled {
+			// the pooled contexts are dropped at random by the race detector
+			continue
+		}
+		allocs := testing.AllocsPerRun(100, func() {
+			app.ServeHTTP(rw, req)
+		})
+		assert.Zero(t, allocs, "allocations for %d params", n)
+	}
+}
+
+func BenchmarkParamsRouteNoAllocations(b *testing.B) {
+	for n := 1; n <= 8; n++ {
+		b.Run(fmt.Sprintf("%d params", n), func(b *testing.B) {
+			app := webapp.New(webapp.WithRouter(New()))
+			route, path := paramsRoute(n)
+			app.GET(route, func(c webapp.Context) error { return nil })
+
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			rw := httptest.NewRecorder()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				app.ServeHTTP(rw, req)
+			}
+		})
+	}
+}
+
+func Test_webapp_router_panic_handler(t *testing.T) {
+	var recovered interface{}
+	r := New(WithPanicHandler(func(c webapp.Context, rcv interface{}) error {
+		recovered = rcv
+		return webapp.NewHTTPError(http.StatusServiceUnavailable)
+	}))
+
+	app := webapp.New(webapp.WithRouter(r))
+	app.GET("/panic", func(c webapp.Context) error {
+		panic("boom")
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/panic", nil)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.Equal(t, "boom", recovered)
+	assert.Equal(t, http.StatusServiceUnavailable, rw.Code)
+}
+
+func Test_router_route_not_found(t *testing.T) {
+	var callstack []string
+	app := webapp.New(webapp.WithRouter(New()))
+	app.GET("/api/users", func(c webapp.Context) error { return c.String(http.StatusOK, "users") })
+	app.RouteNotFound("/*", func(c webapp.Context) error { return c.String(http.StatusNotFound, "root") })
+	app.RouteNotFound("/app/*", func(c webapp.Context) error { return c.HTML(http.StatusNotFound, "<h1>app</h1>") })
+	app.Group("/api", func(next webapp.HandlerFunc) webapp.HandlerFunc {
+		return func(c webapp.Context) error {
+			callstack = append(callstack, "api")
+			return next(c)
+		}
+	}).RouteNotFound("/*rest", func(c webapp.Context) error {
+		return c.JSON(http.StatusNotFound, map[string]string{"rest": c.Param("rest")})
+	})
+
+	tests := map[string]struct {
+		path string
+		code int
+		body string
+	}{
+		"matched route":       {path: "/api/users", code: 200, body: "users"},
+		"root scope":          {path: "/foo", code: 404, body: "root"},
+		"app scope":           {path: "/app/foo/bar", code: 404, body: "<h1>app</h1>"},
+		"app prefix":          {path: "/app", code: 404, body: "<h1>app</h1>"},
+		"api scope with name": {path: "/api/foo/bar", code: 404, body: "{\"rest\":\"/foo/bar\"}\n"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, test.path, nil)
+			rw := httptest.NewRecorder()
+			app.ServeHTTP(rw, req)
+
+			assert.Equal(t, test.code, rw.Code)
+			assert.Equal(t, test.body, rw.Body.String())
+		})
+	}
+
+	assert.Equal(t, []string{"api"}, callstack)
+}
+
+func Test_router_any(t *testing.T) {
+	calls := 0
+	app := webapp.New(webapp.WithRouter(New()))
+	app.Group("/hooks", func(next webapp.HandlerFunc) webapp.HandlerFunc {
+		return func(c webapp.Context) error {
+			calls++
+			return next(c)
+		}
+	}).Any("/{id}", func(c webapp.Context) error { return c.String(http.StatusOK, c.Param("id")) })
+
+	for _, method := range webapp.Methods {
+		t.Run(method, func(t *testing.T) {
+			calls = 0
+			req, _ := http.NewRequest(method, "/hooks/github", nil)
+			rw := httptest.NewRecorder()
+			app.ServeHTTP(rw, req)
+
+			assert.Equal(t, 200, rw.Code)
+			assert.Equal(t, "github", rw.Body.String())
+			assert.Equal(t, 1, calls)
+		})
+	}
+
+	assert.Len(t, app.Routes().All(), len(webapp.Methods))
+}
+
+func Test_router_match(t *testing.T) {
+	handler := func(c webapp.Context) error { return c.NoContent() }
+	app := webapp.New(webapp.WithRouter(New()))
+	routeInfos := app.Match([]string{http.MethodGet, http.MethodPost}, "/proxy", handler)
+
+	assert.Len(t, routeInfos, 2)
+	assert.Equal(t, http.MethodPost, routeInfos[1].Method())
+	assert.Equal(t, webapp.MethodQualifiedName(handlerName(handler), http.MethodPost), routeInfos[1].Name())
+	assert.Equal(t, routeInfos[0], app.Routes().Get(webapp.MethodQualifiedName(handlerName(handler), http.MethodGet)))
+
+	req, _ := http.NewRequest(http.MethodPut, "/proxy", nil)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.Equal(t, 405, rw.Code)
+}
+
+func Test_router_named_routes(t *testing.T) {
+	handler := func(c webapp.Context) error { return c.String(http.StatusOK, c.CurrentRoute().Name()) }
+
+	app := webapp.New(webapp.WithRouter(New()))
+	app.GET("/users/{id}", handler).Named("user.show").Tag("users")
+	app.GET("/people/{id}", handler)
+
+	assert.Equal(t, []string{"users"}, app.Routes().Get("user.show").Meta().Tags)
+	assert.NotNil(t, app.Routes().Get(handlerName(handler)))
+	assert.Panics(t, func() {
+		app.GET("/admins/{id}", handler).Named("user.show")
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/users/12", nil)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+
+	assert.Equal(t, "user.show", rw.Body.String())
+}
+
+func Test_router_host_routing(t *testing.T) {
+	app := webapp.New(
+		webapp.WithRouter(New()),
+		webapp.WithRouterFactory(func() webapp.Router { return New() }),
+	)
+	app.GET("/", func(c webapp.Context) error { return c.String(http.StatusOK, "main") })
+	app.Host("{tenant}.example.com").GET("/users/{id:int}", func(c webapp.Context) error {
+		return c.String(http.StatusOK, c.Param("tenant")+" "+c.Param("id"))
+	})
+
+	tests := map[string]struct {
+		host string
+		path string
+		body string
+	}{
+		"tenant":       {host: "acme.example.com", path: "/users/12", body: "acme 12"},
+		"unknown host": {host: "localhost:8080", path: "/", body: "main"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, test.path, nil)
+			req.Host = test.host
+			rw := httptest.NewRecorder()
+			app.ServeHTTP(rw, req)
+
+			assert.Equal(t, test.body, rw.Body.String())
+		})
+	}
+}
+
+func Test_router_route_matchers(t *testing.T) {
+	reply := func(body string) webapp.HandlerFunc {
+		return func(c webapp.Context) error { return c.String(http.StatusOK, body) }
+	}
+
+	app := webapp.New(webapp.WithRouter(New()))
+	app.GET("/users/{id}", reply("default"))
+	app.With(webapp.MatchMediaType("application/vnd.acme.v2+json")).GET("/users/{id}", reply("v2"))
+	app.With(webapp.MatchHeader("X-Api-Version", "2"), webapp.MatchQuery("debug", "")).GET("/users/{id}", reply("v2 debug"))
+	app.With(webapp.MatchHeader("X-Api-Version", "2")).GET("/files/*filepath", reply("v2 files"))
+	app.GET("/files/*filepath", reply("files"))
+	app.With(webapp.MatchHeader("X-Api-Version", "2")).GET("/posts/{page:int?}", reply("v2 posts"))
+	app.GET("/posts/{page:int?}", reply("posts"))
+
+	tests := map[string]struct {
+		target string
+		header http.Header
+		body   string
+	}{
+		"no matchers":            {target: "/users/1", body: "default"},
+		"media type":             {target: "/users/1", header: http.Header{"Accept": {"application/vnd.acme.v2+json"}}, body: "v2"},
+		"most specific":          {target: "/users/1?debug", header: http.Header{"X-Api-Version": {"2"}, "Accept": {"application/vnd.acme.v2+json"}}, body: "v2 debug"},
+		"catch-all":              {target: "/files/a.txt", body: "files"},
+		"catch-all v2":           {target: "/files/a.txt", header: http.Header{"X-Api-Version": {"2"}}, body: "v2 files"},
+		"optional param":         {target: "/posts/2", body: "posts"},
+		"optional param absent":  {target: "/posts", body: "posts"},
+		"optional param v2":      {target: "/posts/2", header: http.Header{"X-Api-Version": {"2"}}, body: "v2 posts"},
+		"optional param v2 root": {target: "/posts", header: http.Header{"X-Api-Version": {"2"}}, body: "v2 posts"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, test.target, nil)
+			for k, v := range test.header {
+				req.Header[k] = v
+			}
+			rw := httptest.NewRecorder()
+			app.ServeHTTP(rw, req)
+
+			assert.Equal(t, test.body, rw.Body.String())
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+	assert.Equal(t, "Accept, X-Api-Version", rw.Header().Get(webapp.HeaderVary))
+
+	assert.Panics(t, func() {
+		app.With(webapp.MatchMediaType("application/vnd.acme.v2+json")).GET("/users/{id}", reply("duplicate"))
+	})
+	assert.Panics(t, func() {
+		app.GET("/posts/{page:int?}", reply("duplicate"))
+	})
+}
+
+func Test_router_mount(t *testing.T) {
+	billing := webapp.New(webapp.WithRouter(New()))
+	billing.GET("/invoices/{id}", func(c webapp.Context) error {
+		return c.String(http.StatusOK, "invoice "+c.Param("id")+" "+c.Path())
+	})
+
+	app := webapp.New(webapp.WithRouter(New()))
+	app.GET("/status", func(c webapp.Context) error { return c.String(http.StatusOK, "ok") })
+	app.Mount("/billing", billing, webapp.MountShared())
+	app.MountHandler("/metrics", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("metrics " + r.URL.Path))
+	}))
+
+	tests := map[string]struct {
+		target string
+		code   int
+		body   string
+	}{
+		"route":          {target: "/status", code: 200, body: "ok"},
+		"mounted app":    {target: "/billing/invoices/1", code: 200, body: "invoice 1 /invoices/1"},
+		"mounted 404":    {target: "/billing/unknown", code: 404},
+		"handler prefix": {target: "/metrics", code: 200, body: "metrics /"},
+		"handler nested": {target: "/metrics/go", code: 200, body: "metrics /go"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			app.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, test.target, nil))
+
+			assert.Equal(t, test.code, rw.Code)
+			if test.body != "" {
+				assert.Equal(t, test.body, rw.Body.String())
+			}
+		})
+	}
+}
+
+func Test_router_mount_redirect(t *testing.T) {
+	newBilling := func() webapp.WebApp {
+		billing := webapp.New(webapp.WithRouter(New()))
+		billing.GET("/invoices", func(c webapp.Context) error { return c.String(http.StatusOK, "invoices") })
+		return billing
+	}
+
+	app := webapp.New(webapp.WithRouter(New()))
+	app.Mount("/billing", newBilling())
+	app.Mount("/shared", newBilling(), webapp.MountShared())
+	nested := webapp.New(webapp.WithRouter(New()))
+	nested.Mount("/billing", newBilling())
+	app.Mount("/eu", nested)
+
+	tests := map[string]struct {
+		target   string
+		location string
+	}{
+		"trailing slash":        {target: "/billing/invoices/", location: "/billing/invoices"},
+		"trailing slash query":  {target: "/billing/invoices/?page=2", location: "/billing/invoices?page=2"},
+		"fixed path":            {target: "/billing/INVOICES", location: "/billing/invoices"},
+		"shared trailing slash": {target: "/shared/invoices/", location: "/shared/invoices"},
+		"nested trailing slash": {target: "/eu/billing/invoices/", location: "/eu/billing/invoices"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			app.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, test.target, nil))
+
+			assert.Equal(t, http.StatusMovedPermanently, rw.Code)
+			assert.Equal(t, test.location, rw.Header().Get(webapp.HeaderLocation))
+		})
+	}
+}
+
+func Test_router_static(t *testing.T) {
+	files := fstest.MapFS{
+		"index.html":     {Data: []byte("<h1>index</h1>")},
+		"css/site.css":   {Data: []byte("body{}")},
+		"files/note.txt": {Data: []byte("note")},
+	}
+
+	r := New()
+	r.ServeFiles("/files/*filepath", files)
+
+	app := webapp.New(webapp.WithRouter(r))
+	app.Static("/assets", files)
+
+	tests := map[string]struct {
+		method string
+		target string
+		code   int
+		body   string
+	}{
+		"prefix":      {method: http.MethodGet, target: "/assets", code: http.StatusMovedPermanently},
+		"index":       {method: http.MethodGet, target: "/assets/", code: 200, body: "<h1>index</h1>"},
+		"file":        {method: http.MethodGet, target: "/assets/css/site.css", code: 200, body: "body{}"},
+		"head":        {method: http.MethodHead, target: "/assets/css/site.css", code: 200},
+		"not found":   {method: http.MethodGet, target: "/assets/css/missing.css", code: 404},
+		"post":        {method: http.MethodPost, target: "/assets/css/site.css", code: 405},
+		"serve files": {method: http.MethodGet, target: "/files/files/note.txt", code: 200, body: "note"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			app.ServeHTTP(rw, httptest.NewRequest(test.method, test.target, nil))
+
+			assert.Equal(t, test.code, rw.Code)
+			if test.body != "" {
+				assert.Equal(t, test.body, rw.Body.String())
+			}
+		})
+	}
+}
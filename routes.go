@@ -1,7 +1,11 @@
 package webapp
 
 import (
+	"fmt"
+	"net/url"
 	"sort"
+	"strconv"
+	"strings"
 )
 
 type RouteInfo interface {
@@ -17,16 +21,47 @@ type RouteInfo interface {
 	// Params returns all the registered param names in the url
 	Params() []string
 
-	// Reverse generates a URL from route and provided parameters.
-	Reverse(params ...interface{}) string
+	// ParamSpecs returns the params in the url with their constraint
+	ParamSpecs() []ParamSpec
+
+	// Reverse generates a URL from route and provided parameters in the order of the params in the path.
+	// The values are escaped, an error is returned when a param is missing or too many params are provided.
+	Reverse(params ...interface{}) (string, error)
+
+	// Named sets the name of the route, used to look up the route in Routes.
+	// Registering two routes with the same name panics.
+	Named(name string) RouteInfo
+
+	// Tag adds tags to the route metadata
+	Tag(tags ...string) RouteInfo
+
+	// Describe sets the summary in the route metadata
+	Describe(summary string) RouteInfo
+
+	// Deprecate marks the route as deprecated in the route metadata
+	Deprecate() RouteInfo
+
+	// Meta returns the metadata attached to the route
+	Meta() RouteMeta
+}
+
+// RouteMeta is the descriptive metadata of a route, the router does not use it but it is available for tooling
+// like documentation generators.
+type RouteMeta struct {
+	Tags       []string
+	Summary    string
+	Deprecated bool
 }
 
 type Routes interface {
 	// URI generates a URI from handler.
-	URI(handler HandlerFunc, params ...interface{}) string
+	URI(handler HandlerFunc, params ...interface{}) (string, error)
 
 	// Reverse generates a URL from route name and provided parameters.
-	Reverse(name string, params ...interface{}) string
+	Reverse(name string, params ...interface{}) (string, error)
+
+	// ReverseWithQuery generates a URL from route name and provided parameters with the encoded query appended.
+	ReverseWithQuery(name string, params []interface{}, query url.Values) (string, error)
 
 	// Get returns the registered routes by name
 	Get(name string) RouteInfo
@@ -35,18 +70,103 @@ type Routes interface {
 	All() []RouteInfo
 }
 
+// MethodQualifiedName returns the name for a route registered for multiple methods with Any or Match,
+// the method is appended to the handler name (`main.webhook#POST`)
+func MethodQualifiedName(name, method string) string {
+	return name + "#" + method
+}
+
 type routes map[string]RouteInfo
 
-func (r routes) URI(handler HandlerFunc, params ...interface{}) string {
-	//TODO implement me
-	panic("implement me")
+// register adds the route under its name. When the derived handler name is already taken by another route the
+// route is renamed to the name qualified with the method and path, routes with the same method and path but different
+// request matchers get a sequence number appended.
+func (r routes) register(routeInfo RouteInfo) RouteInfo {
+	route := &registeredRoute{RouteInfo: routeInfo, routes: r}
+	name := routeInfo.Name()
+	if _, ok := r[name]; ok {
+		qualified := MethodQualifiedName(strings.TrimSuffix(name, "#"+routeInfo.Method()), routeInfo.Method()) + " " + routeInfo.Path()
+		name = qualified
+		for i := 2; ; i++ {
+			if _, ok := r[name]; !ok {
+				break
+			}
+			name = qualified + " " + strconv.Itoa(i)
+		}
+		routeInfo.Named(name)
+	}
+	r[name] = route
+	return route
 }
 
-func (r routes) Reverse(name string, params ...interface{}) string {
+// registeredRoute keeps the routes registry in sync when the route is renamed
+type registeredRoute struct {
+	RouteInfo
+	routes routes
+}
+
+func (r *registeredRoute) Named(name string) RouteInfo {
+	if name == "" {
+		panic("route name must not be empty")
+	}
+	if name == r.Name() {
+		return r
+	}
+	if _, ok := r.routes[name]; ok {
+		panic("route name '" + name + "' is already registered")
+	}
+
+	if existing, ok := r.routes[r.Name()]; ok && existing == RouteInfo(r) {
+		delete(r.routes, r.Name())
+	}
+	r.RouteInfo.Named(name)
+	r.routes[name] = r
+	return r
+}
+
+func (r *registeredRoute) Tag(tags ...string) RouteInfo {
+	r.RouteInfo.Tag(tags...)
+	return r
+}
+
+func (r *registeredRoute) Describe(summary string) RouteInfo {
+	r.RouteInfo.Describe(summary)
+	return r
+}
+
+func (r *registeredRoute) Deprecate() RouteInfo {
+	r.RouteInfo.Deprecate()
+	return r
+}
+
+func (r routes) URI(handler HandlerFunc, params ...interface{}) (string, error) {
+	name := handlerName(handler)
+	if routeInfo, ok := r[name]; ok {
+		return routeInfo.Reverse(params...)
+	}
+
+	// routes registered with Any or Match are named with the method qualified handler name
+	for _, routeInfo := range r.All() {
+		if strings.HasPrefix(routeInfo.Name(), name+"#") {
+			return routeInfo.Reverse(params...)
+		}
+	}
+	return "", fmt.Errorf("%w: no route for handler %s", ErrRouteNotFound, name)
+}
+
+func (r routes) Reverse(name string, params ...interface{}) (string, error) {
 	if routeInfo, ok := r[name]; ok {
 		return routeInfo.Reverse(params...)
 	}
-	return ""
+	return "", fmt.Errorf("%w: %s", ErrRouteNotFound, name)
+}
+
+func (r routes) ReverseWithQuery(name string, params []interface{}, query url.Values) (string, error) {
+	uri, err := r.Reverse(name, params...)
+	if err != nil || len(query) == 0 {
+		return uri, err
+	}
+	return uri + "?" + query.Encode(), nil
 }
 
 func (r routes) Get(name string) RouteInfo {
@@ -2,6 +2,8 @@ package webapp
 
 import (
 	stdContext "context"
+	"crypto/tls"
+	"net"
 	"net/http"
 )
 
@@ -11,16 +13,40 @@ type Server interface {
 	ServeHTTP(w http.ResponseWriter, r *http.Request)
 
 	// Start starts an HTTP server.
+	// It blocks until the server is stopped and returns `http.ErrServerClosed` after a `Close` or `Shutdown`.
+	// The address is a tcp address like `:8080`, or an address with a scheme: `tcp://localhost:8080`,
+	// `unix:///run/app.sock` for a unix domain socket or `fd://3` for an inherited file descriptor.
+	// With systemd socket activation the file descriptor can also be referenced by name, like `fd://http`.
 	Start(address string) error
 
+	// StartListener starts an HTTP server that accepts connections on the provided listener.
+	StartListener(l net.Listener) error
+
+	// StartTLS starts an HTTPS server.
+	// The certFile and keyFile can be a path to a PEM encoded file or the PEM encoded content as a []byte.
+	// Certificates loaded from files are reloaded when the files change on disk, no restart is required to rotate them.
+	StartTLS(address string, certFile, keyFile interface{}) error
+
+	// StartTLSConfig starts an HTTPS server with the provided tls config.
+	StartTLSConfig(address string, tlsConfig *tls.Config) error
+
 	// StartServer starts a custom http server.
+	// The webapp is used as handler when the server has no handler set, and TLS is used when a TLS config is set.
 	StartServer(s *http.Server) (err error)
 
-	// Close immediately stops the server.
+	// Close immediately stops all the started servers, active connections are closed.
 	// It internally calls `http.Server#Close()`.
 	Close() error
 
-	// Shutdown stops the server gracefully.
+	// Shutdown stops all the started servers gracefully. The servers stop accepting new connections and wait for
+	// the in-flight requests to finish until the context expires. The registered shutdown hooks are run afterwards.
 	// It internally calls `http.Server#Shutdown()`.
 	Shutdown(ctx stdContext.Context) error
+
+	// RegisterOnShutdown registers a function to call on Shutdown, after the servers have stopped handling requests.
+	// Use it to release resources that are used by the handlers, like database connections.
+	RegisterOnShutdown(f func())
+
+	// ListenerAddr returns the network address of the first started server, or nil if no server is running.
+	ListenerAddr() net.Addr
 }
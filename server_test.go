@@ -0,0 +1,133 @@
+package webapp
+
+import (
+	stdContext "context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startTestServer starts the webapp on a random local port and waits until it accepts connections
+func startTestServer(T *testing.T, app WebApp) (net.Addr, chan error) {
+	done := make(chan error, 1)
+	go func() {
+		done <- app.Start("127.0.0.1:0")
+	}()
+
+	require.Eventually(T, func() bool {
+		return app.ListenerAddr() != nil
+	}, time.Second, time.Millisecond)
+
+	return app.ListenerAddr(), done
+}
+
+// slowHandler responds after the delay and signals when the request is being handled
+func slowHandler(delay time.Duration, started chan struct{}) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			close(started)
+			time.Sleep(delay)
+			return c.String(http.StatusOK, "done")
+		}
+	}
+}
+
+func Test_webapp_shutdown_drains_in_flight_requests(T *testing.T) {
+	app := New()
+
+	started := make(chan struct{})
+	app.Pre(slowHandler(200*time.Millisecond, started))
+
+	hookCalled := false
+	app.RegisterOnShutdown(func() {
+		hookCalled = true
+	})
+
+	addr, done := startTestServer(T, app)
+
+	type result struct {
+		code int
+		body string
+		err  error
+	}
+	inFlight := make(chan result, 1)
+	go func() {
+		res, err := http.Get("http://" + addr.String() + "/slow")
+		if err != nil {
+			inFlight <- result{err: err}
+			return
+		}
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		inFlight <- result{code: res.StatusCode, body: string(body), err: err}
+	}()
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() {
+		ctx, cancel := stdContext.WithTimeout(stdContext.Background(), 2*time.Second)
+		defer cancel()
+		shutdown <- app.Shutdown(ctx)
+	}()
+
+	// new connections are refused as soon as the listener is closed
+	assert.Eventually(T, func() bool {
+		conn, err := net.Dial("tcp", addr.String())
+		if err == nil {
+			conn.Close()
+		}
+		return err != nil
+	}, time.Second, 5*time.Millisecond)
+
+	res := <-inFlight
+	require.NoError(T, res.err)
+	assert.Equal(T, http.StatusOK, res.code)
+	assert.Equal(T, "done", res.body)
+
+	assert.NoError(T, <-shutdown)
+	assert.ErrorIs(T, <-done, http.ErrServerClosed)
+	assert.True(T, hookCalled)
+	assert.Nil(T, app.ListenerAddr())
+}
+
+func Test_webapp_shutdown_stops_waiting_when_context_expires(T *testing.T) {
+	app := New()
+
+	started := make(chan struct{})
+	app.Pre(slowHandler(500*time.Millisecond, started))
+
+	addr, done := startTestServer(T, app)
+	go http.Get("http://" + addr.String() + "/slow")
+	<-started
+
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(T, app.Shutdown(ctx), stdContext.DeadlineExceeded)
+	assert.ErrorIs(T, <-done, http.ErrServerClosed)
+}
+
+func Test_webapp_close_stops_immediately(T *testing.T) {
+	app := New()
+
+	started := make(chan struct{})
+	app.Pre(slowHandler(500*time.Millisecond, started))
+
+	addr, done := startTestServer(T, app)
+
+	inFlight := make(chan error, 1)
+	go func() {
+		_, err := http.Get("http://" + addr.String() + "/slow")
+		inFlight <- err
+	}()
+	<-started
+
+	assert.NoError(T, app.Close())
+	assert.ErrorIs(T, <-done, http.ErrServerClosed)
+	assert.Error(T, <-inFlight)
+}
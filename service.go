@@ -10,4 +10,7 @@ type Service interface {
 
 	JsonEncoder() JSONEncoding
 	XmlEncoder() XMLEncoding
+
+	// Encodings returns the registered encodings, the json and xml encodings and the encodings added with WithEncoding
+	Encodings() []Encoding
 }
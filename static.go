@@ -0,0 +1,269 @@
+package webapp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StaticMethods are the methods the static file routes are registered for
+var StaticMethods = []string{http.MethodGet, http.MethodHead}
+
+const indexFile = "index.html"
+
+// precompressedEncodings are the content encodings of the precompressed sibling files in order of preference
+var precompressedEncodings = []struct {
+	encoding  string
+	extension string
+}{
+	{encoding: "br", extension: ".br"},
+	{encoding: "gzip", extension: ".gz"},
+}
+
+// StaticOption configures how the static files are served
+type StaticOption func(*staticFiles)
+
+// StaticIndexFallback serves the index.html of the root for the paths that do not exist, used for single page
+// applications that handle the routing in the browser.
+func StaticIndexFallback() StaticOption {
+	return func(s *staticFiles) {
+		s.fallback = true
+	}
+}
+
+// StaticBrowse enables the listing of the directories without an index.html, directory listing is disabled by default.
+func StaticBrowse() StaticOption {
+	return func(s *staticFiles) {
+		s.browse = true
+	}
+}
+
+// StaticHandler returns the handler serving the file of the file system for the path in the `filepath` param, an
+// empty param serves the root. The path is cleaned with CleanPath so it cannot escape the root of the file system.
+//
+// Range requests and the If-Modified-Since and If-None-Match conditional headers are supported, the ETag is the hash
+// of the content and is calculated once per file. A directory serves its index.html. When a `.br` or `.gz` sibling of
+// the file exists and the client accepts the encoding, the precompressed file is served.
+func StaticHandler(fsys fs.FS, options ...StaticOption) HandlerFunc {
+	s := &staticFiles{
+		fsys:  fsys,
+		etags: &sync.Map{},
+	}
+	for _, option := range options {
+		option(s)
+	}
+
+	return func(c Context) error {
+		return s.serve(c, c.Param("filepath"))
+	}
+}
+
+type staticFiles struct {
+	fsys     fs.FS
+	fallback bool
+	browse   bool
+
+	// etags caches the calculated etag per file, no etag is sent when nil
+	etags *sync.Map
+}
+
+type staticETag struct {
+	modTime time.Time
+	size    int64
+	etag    string
+}
+
+// serve sends the file for the request path
+func (s *staticFiles) serve(c Context, name string) error {
+	name = strings.Trim(CleanPath("/"+name), "/")
+	if name == "" {
+		name = "."
+	}
+	if !fs.ValidPath(name) {
+		return ErrNotFound
+	}
+
+	info, err := fs.Stat(s.fsys, name)
+	if err == nil && info.IsDir() {
+		// redirect to the path with a trailing slash so relative links in the directory resolve
+		if p := c.Request().URL.Path; !strings.HasSuffix(p, "/") {
+			u := *c.Request().URL
+			u.Path = BasePath(c.Request()) + p + "/"
+			u.RawPath = ""
+			return c.Redirect(http.StatusMovedPermanently, u.String())
+		}
+
+		index := path.Join(name, indexFile)
+		if info, err = fs.Stat(s.fsys, index); err == nil && !info.IsDir() {
+			name = index
+		} else if s.browse {
+			return s.list(c, name)
+		} else {
+			err = fs.ErrNotExist
+		}
+	}
+
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if !s.fallback {
+			return ErrNotFound
+		}
+		name = indexFile
+		if info, err = fs.Stat(s.fsys, name); err != nil || info.IsDir() {
+			return ErrNotFound
+		}
+	}
+	return s.serveFile(c, name, info)
+}
+
+// file sends the file, a directory sends its index.html
+func (s *staticFiles) file(c Context, name string) error {
+	info, err := fs.Stat(s.fsys, name)
+	if err == nil && info.IsDir() {
+		name = path.Join(name, indexFile)
+		info, err = fs.Stat(s.fsys, name)
+	}
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ErrNotFound
+		}
+		return err
+	}
+	if info.IsDir() {
+		return ErrNotFound
+	}
+	return s.serveFile(c, name, info)
+}
+
+// serveFile sends the content of the file or the precompressed sibling accepted by the client
+func (s *staticFiles) serveFile(c Context, name string, info fs.FileInfo) error {
+	header := c.Response().Header()
+	content, contentInfo := name, info
+	vary := false
+	for _, precompressed := range precompressedEncodings {
+		sibling, err := fs.Stat(s.fsys, name+precompressed.extension)
+		if err != nil || sibling.IsDir() {
+			continue
+		}
+		vary = true
+		if content == name && acceptsEncoding(c.Request().Header.Values(HeaderAcceptEncoding), precompressed.encoding) {
+			content, contentInfo = name+precompressed.extension, sibling
+			header.Set(HeaderContentEncoding, precompressed.encoding)
+		}
+	}
+	if vary {
+		header.Add(HeaderVary, HeaderAcceptEncoding)
+	}
+
+	// the content type of the precompressed file is based on the name of the original file
+	if content != name && header.Get(HeaderContentType) == "" {
+		contentType := mime.TypeByExtension(path.Ext(name))
+		if contentType == "" {
+			contentType = MIMEOctetStream
+		}
+		header.Set(HeaderContentType, contentType)
+	}
+
+	f, err := s.fsys.Open(content)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	rs, ok := f.(io.ReadSeeker)
+	if !ok {
+		b, err := io.ReadAll(f)
+		if err != nil {
+			return err
+		}
+		rs = bytes.NewReader(b)
+	}
+
+	if s.etags != nil {
+		etag, err := s.etag(content, contentInfo, rs)
+		if err != nil {
+			return err
+		}
+		header.Set(HeaderETag, etag)
+	}
+
+	http.ServeContent(c.Response(), c.Request(), name, contentInfo.ModTime(), rs)
+	return nil
+}
+
+// etag returns the cached etag of the file, the etag is calculated again when the file is modified
+func (s *staticFiles) etag(name string, info fs.FileInfo, rs io.ReadSeeker) (string, error) {
+	if cached, ok := s.etags.Load(name); ok {
+		e := cached.(staticETag)
+		if e.modTime.Equal(info.ModTime()) && e.size == info.Size() {
+			return e.etag, nil
+		}
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, rs); err != nil {
+		return "", err
+	}
+	if _, err := rs.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	etag := `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+	s.etags.Store(name, staticETag{modTime: info.ModTime(), size: info.Size(), etag: etag})
+	return etag, nil
+}
+
+// list sends a html page with links to the entries of the directory
+func (s *staticFiles) list(c Context, dir string) error {
+	entries, err := fs.ReadDir(s.fsys, dir)
+	if err != nil {
+		return err
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString("<!doctype html>\n<meta name=\"viewport\" content=\"width=device-width\">\n<pre>\n")
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			name += "/"
+		}
+		link := url.URL{Path: name}
+		fmt.Fprintf(&sb, "<a href=\"%s\">%s</a>\n", html.EscapeString(link.String()), html.EscapeString(name))
+	}
+	sb.WriteString("</pre>\n")
+	return c.HTML(http.StatusOK, sb.String())
+}
+
+// acceptsEncoding reports if the content coding is listed in the Accept-Encoding header values with a non zero quality
+func acceptsEncoding(values []string, encoding string) bool {
+	for _, value := range values {
+		for _, accept := range strings.Split(value, ",") {
+			coding, params, _ := strings.Cut(strings.TrimSpace(accept), ";")
+			if !strings.EqualFold(strings.TrimSpace(coding), encoding) && strings.TrimSpace(coding) != "*" {
+				continue
+			}
+			if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+				if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package webapp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var staticModTime = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func newStaticFS() fstest.MapFS {
+	return fstest.MapFS{
+		"index.html":          {Data: []byte("<h1>index</h1>"), ModTime: staticModTime},
+		"app.js":              {Data: []byte("console.log('app')"), ModTime: staticModTime},
+		"app.js.br":           {Data: []byte("brotli"), ModTime: staticModTime},
+		"app.js.gz":           {Data: []byte("gzip"), ModTime: staticModTime},
+		"docs/readme.txt":     {Data: []byte("0123456789"), ModTime: staticModTime},
+		"images/logo.svg":     {Data: []byte("<svg/>"), ModTime: staticModTime},
+		"images/nested/a.txt": {Data: []byte("a"), ModTime: staticModTime},
+	}
+}
+
+func serveStatic(app WebApp, method, target string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rw := httptest.NewRecorder()
+	app.ServeHTTP(rw, req)
+	return rw
+}
+
+func Test_static(T *testing.T) {
+	tests := map[string]struct {
+		options  []StaticOption
+		method   string
+		target   string
+		header   http.Header
+		code     int
+		body     string
+		encoding string
+		location string
+	}{
+		"file":                   {target: "/assets/docs/readme.txt", code: 200, body: "0123456789"},
+		"head":                   {method: http.MethodHead, target: "/assets/docs/readme.txt", code: 200},
+		"index":                  {target: "/assets/", code: 200, body: "<h1>index</h1>"},
+		"redirect directory":     {target: "/assets?v=1", code: http.StatusMovedPermanently, location: "/assets/?v=1"},
+		"redirect sub directory": {target: "/assets/docs", code: http.StatusMovedPermanently, location: "/assets/docs/"},
+		"no directory listing":   {target: "/assets/docs/", code: 404},
+		"directory listing":      {options: []StaticOption{StaticBrowse()}, target: "/assets/images/", code: 200, body: "<!doctype html>\n<meta name=\"viewport\" content=\"width=device-width\">\n<pre>\n<a href=\"logo.svg\">logo.svg</a>\n<a href=\"nested/\">nested/</a>\n</pre>\n"},
+		"not found":              {target: "/assets/missing.js", code: 404},
+		"index fallback":         {options: []StaticOption{StaticIndexFallback()}, target: "/assets/users/1", code: 200, body: "<h1>index</h1>"},
+		"path traversal":         {target: "/assets/../static.go", code: 404},
+		"encoded path traversal": {target: "/assets/%2e%2e/static.go", code: 404},
+		"range":                  {target: "/assets/docs/readme.txt", header: http.Header{"Range": {"bytes=2-4"}}, code: http.StatusPartialContent, body: "234"},
+		"not modified since":     {target: "/assets/docs/readme.txt", header: http.Header{"If-Modified-Since": {staticModTime.Format(http.TimeFormat)}}, code: http.StatusNotModified},
+		"modified since":         {target: "/assets/docs/readme.txt", header: http.Header{"If-Modified-Since": {staticModTime.Add(-time.Hour).Format(http.TimeFormat)}}, code: 200, body: "0123456789"},
+		"brotli":                 {target: "/assets/app.js", header: http.Header{"Accept-Encoding": {"gzip, br"}}, code: 200, body: "brotli", encoding: "br"},
+		"gzip":                   {target: "/assets/app.js", header: http.Header{"Accept-Encoding": {"gzip, br;q=0"}}, code: 200, body: "gzip", encoding: "gzip"},
+		"identity":               {target: "/assets/app.js", code: 200, body: "console.log('app')"},
+	}
+
+	for name, test := range tests {
+		T.Run(name, func(T *testing.T) {
+			app := New()
+			app.Static("/assets", newStaticFS(), test.options...)
+
+			method := test.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			rw := serveStatic(app, method, test.target, test.header)
+
+			assert.Equal(T, test.code, rw.Code)
+			if test.body != "" {
+				assert.Equal(T, test.body, rw.Body.String())
+			}
+			assert.Equal(T, test.encoding, rw.Header().Get(HeaderContentEncoding))
+			assert.Equal(T, test.location, rw.Header().Get(HeaderLocation))
+		})
+	}
+}
+
+func Test_static_precompressed_headers(T *testing.T) {
+	app := New()
+	app.Static("/", newStaticFS())
+
+	rw := serveStatic(app, http.MethodGet, "/app.js", http.Header{"Accept-Encoding": {"br"}})
+
+	assert.Equal(T, http.StatusOK, rw.Code)
+	assert.Equal(T, "text/javascript; charset=utf-8", rw.Header().Get(HeaderContentType))
+	assert.Equal(T, HeaderAcceptEncoding, rw.Header().Get(HeaderVary))
+
+	rw = serveStatic(app, http.MethodGet, "/docs/readme.txt", nil)
+	assert.Empty(T, rw.Header().Get(HeaderVary))
+}
+
+func Test_static_etag(T *testing.T) {
+	app := New()
+	app.Static("/", newStaticFS())
+
+	rw := serveStatic(app, http.MethodGet, "/docs/readme.txt", nil)
+	etag := rw.Header().Get(HeaderETag)
+	require.NotEmpty(T, etag)
+
+	rw = serveStatic(app, http.MethodGet, "/docs/readme.txt", http.Header{"If-None-Match": {etag}})
+	assert.Equal(T, http.StatusNotModified, rw.Code)
+
+	// the precompressed content has its own etag
+	rw = serveStatic(app, http.MethodGet, "/app.js", nil)
+	identity := rw.Header().Get(HeaderETag)
+	rw = serveStatic(app, http.MethodGet, "/app.js", http.Header{"Accept-Encoding": {"gzip"}})
+	assert.NotEqual(T, identity, rw.Header().Get(HeaderETag))
+}
+
+func Test_context_file(T *testing.T) {
+	dir := T.TempDir()
+	require.NoError(T, os.WriteFile(filepath.Join(dir, "report.csv"), []byte("a,b\n1,2\n"), 0o644))
+	require.NoError(T, os.Mkdir(filepath.Join(dir, "site"), 0o755))
+	require.NoError(T, os.WriteFile(filepath.Join(dir, "site", "index.html"), []byte("<h1>site</h1>"), 0o644))
+
+	tests := map[string]struct {
+		handler     HandlerFunc
+		code        int
+		body        string
+		disposition string
+	}{
+		"file":       {handler: func(c Context) error { return c.File(filepath.Join(dir, "report.csv")) }, code: 200, body: "a,b\n1,2\n"},
+		"index":      {handler: func(c Context) error { return c.File(filepath.Join(dir, "site")) }, code: 200, body: "<h1>site</h1>"},
+		"not found":  {handler: func(c Context) error { return c.File(filepath.Join(dir, "missing.csv")) }, code: 404},
+		"attachment": {handler: func(c Context) error { return c.Attachment(filepath.Join(dir, "report.csv"), "report 2024.csv") }, code: 200, body: "a,b\n1,2\n", disposition: `attachment; filename="report 2024.csv"`},
+		"inline":     {handler: func(c Context) error { return c.Inline(filepath.Join(dir, "report.csv"), "rapport-é.csv") }, code: 200, disposition: `inline; filename*=utf-8''rapport-%C3%A9.csv`},
+	}
+
+	for name, test := range tests {
+		T.Run(name, func(T *testing.T) {
+			app := New()
+			app.GET("/file", test.handler)
+
+			rw := serveStatic(app, http.MethodGet, "/file", nil)
+
+			assert.Equal(T, test.code, rw.Code)
+			if test.body != "" {
+				assert.Equal(T, test.body, rw.Body.String())
+			}
+			assert.Equal(T, test.disposition, rw.Header().Get(HeaderContentDisposition))
+		})
+	}
+}
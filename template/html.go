@@ -19,6 +19,10 @@ func HTMLTemplate(options ...Option) webapp.Renderer {
 		return template.JS(s)
 	}
 
+	// reverse generates the url for the named route, `{{ reverse "main.showUser" .id }}`, the routes are bound with
+	// WithRoutes or by WithHTMLTemplate
+	funcMap["reverse"] = reverse(nil)
+
 	t := template.New("").Funcs(funcMap)
 	for _, option := range options {
 		option(t)
@@ -27,10 +31,28 @@ func HTMLTemplate(options ...Option) webapp.Renderer {
 	return &htmlTemplate{template: t}
 }
 
+// WithHTMLTemplate returns the webapp option that sets the html template renderer, the reverse template func is bound
+// to the routes of the webapp
+func WithHTMLTemplate(options ...Option) webapp.Option {
+	return func(app webapp.WebApp) {
+		webapp.WithRenderer(HTMLTemplate(append(options[:len(options):len(options)], WithRoutes(app.Routes()))...))(app)
+	}
+}
+
 type htmlTemplate struct {
 	template *template.Template
 }
 
+// reverse returns the reverse template func generating the urls with the routes
+func reverse(routes webapp.Routes) func(name string, params ...interface{}) (string, error) {
+	return func(name string, params ...interface{}) (string, error) {
+		if routes == nil {
+			return "", webapp.ErrRouteNotFound
+		}
+		return routes.Reverse(name, params...)
+	}
+}
+
 func (t *htmlTemplate) Render(_ webapp.Context, w io.Writer, name string, data interface{}) error {
 	return t.template.ExecuteTemplate(w, name, data)
 }
@@ -1,6 +1,7 @@
 package template
 
 import (
+	"github.com/mbict/webapp"
 	"html/template"
 	"io/fs"
 )
@@ -41,3 +42,10 @@ func FromFS(fs fs.FS, patterns ...string) Option {
 		}
 	}
 }
+
+// WithRoutes binds the reverse template func to the routes, like the routes of the webapp rendering the templates
+func WithRoutes(routes webapp.Routes) Option {
+	return func(t *template.Template) {
+		t.Funcs(template.FuncMap{"reverse": reverse(routes)})
+	}
+}
@@ -0,0 +1,148 @@
+package webapp
+
+import (
+	"crypto/tls"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+func (a *webapp) StartTLS(address string, certFile, keyFile interface{}) error {
+	getCertificate, err := certificateLoader(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+
+	return a.StartTLSConfig(address, &tls.Config{
+		GetCertificate: getCertificate,
+	})
+}
+
+func (a *webapp) StartTLSConfig(address string, tlsConfig *tls.Config) error {
+	server := new(http.Server)
+	server.Addr = address
+	server.TLSConfig = tlsConfig
+	return a.StartServer(server)
+}
+
+type getCertificateFunc func(*tls.ClientHelloInfo) (*tls.Certificate, error)
+
+// certificateLoader returns the certificate callback for the tls config. When both cert and key are file paths the
+// certificate is reloaded when one of the files changes, PEM content is loaded once.
+func certificateLoader(certFile, keyFile interface{}) (getCertificateFunc, error) {
+	certPath, certIsPath := certFile.(string)
+	keyPath, keyIsPath := keyFile.(string)
+	if certIsPath && keyIsPath {
+		r := &certificateReloader{
+			certFile: certPath,
+			keyFile:  keyPath,
+		}
+		if err := r.reload(); err != nil {
+			return nil, err
+		}
+		return r.GetCertificate, nil
+	}
+
+	certPEM, err := filepathOrContent(certFile)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPEM, err := filepathOrContent(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		return &cert, nil
+	}, nil
+}
+
+func filepathOrContent(fileOrContent interface{}) ([]byte, error) {
+	switch v := fileOrContent.(type) {
+	case string:
+		return os.ReadFile(v)
+	case []byte:
+		return v, nil
+	default:
+		return nil, ErrInvalidCertOrKeyType
+	}
+}
+
+// fileStamp is used to detect changes of a file on disk
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func statFile(name string) (fileStamp, error) {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return fileStamp{}, err
+	}
+	return fileStamp{modTime: fi.ModTime(), size: fi.Size()}, nil
+}
+
+// certificateReloader serves the certificate from the cert and key file and reloads them when the files change
+type certificateReloader struct {
+	certFile string
+	keyFile  string
+
+	lock      sync.RWMutex
+	cert      *tls.Certificate
+	certStamp fileStamp
+	keyStamp  fileStamp
+}
+
+// GetCertificate checks the files for changes on every handshake, which is cheap compared to the handshake itself.
+// If a changed certificate cannot be loaded, for example because it is only partially written, the previous
+// certificate is served until the files are valid again.
+func (r *certificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	certStamp, certErr := statFile(r.certFile)
+	keyStamp, keyErr := statFile(r.keyFile)
+
+	r.lock.RLock()
+	cert := r.cert
+	changed := certStamp != r.certStamp || keyStamp != r.keyStamp
+	r.lock.RUnlock()
+
+	if changed && certErr == nil && keyErr == nil {
+		if err := r.reload(); err == nil {
+			r.lock.RLock()
+			cert = r.cert
+			r.lock.RUnlock()
+		}
+	}
+	return cert, nil
+}
+
+func (r *certificateReloader) reload() error {
+	certStamp, err := statFile(r.certFile)
+	if err != nil {
+		return err
+	}
+
+	keyStamp, err := statFile(r.keyFile)
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.cert = &cert
+	r.certStamp = certStamp
+	r.keyStamp = keyStamp
+	return nil
+}
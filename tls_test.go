@@ -0,0 +1,141 @@
+package webapp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// selfSignedCertificate generates a PEM encoded certificate and key for 127.0.0.1 with the provided serial number
+func selfSignedCertificate(T *testing.T, serial int64) (certPEM []byte, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(T, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "webapp test"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(T, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(T, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// servedCertificateSerial does a request over a new connection and returns the serial of the served certificate
+func servedCertificateSerial(T *testing.T, addr net.Addr) int64 {
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+	defer client.CloseIdleConnections()
+
+	res, err := client.Get("https://" + addr.String() + "/")
+	require.NoError(T, err)
+	defer res.Body.Close()
+
+	require.NotNil(T, res.TLS)
+	return res.TLS.PeerCertificates[0].SerialNumber.Int64()
+}
+
+func startTestTLSServer(T *testing.T, app WebApp, certFile, keyFile interface{}) net.Addr {
+	go app.StartTLS("127.0.0.1:0", certFile, keyFile)
+
+	require.Eventually(T, func() bool {
+		return app.ListenerAddr() != nil
+	}, time.Second, time.Millisecond, "server did not start")
+
+	T.Cleanup(func() {
+		app.Close()
+	})
+	return app.ListenerAddr()
+}
+
+func Test_webapp_start_tls_reloads_changed_certificate_files(T *testing.T) {
+	dir := T.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	certPEM, keyPEM := selfSignedCertificate(T, 1)
+	require.NoError(T, os.WriteFile(certFile, certPEM, 0600))
+	require.NoError(T, os.WriteFile(keyFile, keyPEM, 0600))
+
+	addr := startTestTLSServer(T, New(), certFile, keyFile)
+	assert.Equal(T, int64(1), servedCertificateSerial(T, addr))
+
+	// rotate the certificate, the modification time is moved forward to not depend on the file system resolution
+	certPEM, keyPEM = selfSignedCertificate(T, 2)
+	require.NoError(T, os.WriteFile(certFile, certPEM, 0600))
+	require.NoError(T, os.WriteFile(keyFile, keyPEM, 0600))
+	future := time.Now().Add(time.Minute)
+	require.NoError(T, os.Chtimes(certFile, future, future))
+	require.NoError(T, os.Chtimes(keyFile, future, future))
+
+	assert.Equal(T, int64(2), servedCertificateSerial(T, addr))
+}
+
+func Test_webapp_start_tls_keeps_serving_previous_certificate_on_invalid_files(T *testing.T) {
+	dir := T.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	certPEM, keyPEM := selfSignedCertificate(T, 1)
+	require.NoError(T, os.WriteFile(certFile, certPEM, 0600))
+	require.NoError(T, os.WriteFile(keyFile, keyPEM, 0600))
+
+	addr := startTestTLSServer(T, New(), certFile, keyFile)
+
+	require.NoError(T, os.WriteFile(certFile, []byte("partially written"), 0600))
+	assert.Equal(T, int64(1), servedCertificateSerial(T, addr))
+}
+
+func Test_webapp_start_tls_with_pem_content(T *testing.T) {
+	certPEM, keyPEM := selfSignedCertificate(T, 3)
+
+	addr := startTestTLSServer(T, New(), certPEM, keyPEM)
+	assert.Equal(T, int64(3), servedCertificateSerial(T, addr))
+}
+
+func Test_webapp_start_tls_invalid_cert_type(T *testing.T) {
+	err := New().StartTLS("127.0.0.1:0", 123, []byte{})
+	assert.ErrorIs(T, err, ErrInvalidCertOrKeyType)
+}
+
+func Test_webapp_start_tls_config(T *testing.T) {
+	certPEM, keyPEM := selfSignedCertificate(T, 4)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(T, err)
+
+	app := New()
+	go app.StartTLSConfig("127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	require.Eventually(T, func() bool {
+		return app.ListenerAddr() != nil
+	}, time.Second, time.Millisecond)
+	defer app.Close()
+
+	assert.Equal(T, int64(4), servedCertificateSerial(T, app.ListenerAddr()))
+}
@@ -2,8 +2,10 @@ package webapp
 
 import (
 	stdContext "context"
+	"errors"
 	"net"
 	"net/http"
+	"slices"
 	"sync"
 )
 
@@ -14,19 +16,47 @@ type WebApp interface {
 	// Pre adds middleware to the chain which is run before router.
 	Pre(middleware ...MiddlewareFunc)
 
+	// Host returns the route group for the requests with a matching host, the port of the request host is ignored.
+	// The pattern is a literal host (`api.example.com`), can have params as label (`{tenant}.example.com`) which
+	// are available with Context.Param, or start with a wildcard which matches one or more labels
+	// (`*.example.com`). Literal hosts are matched first, then the patterns with the most literal labels.
+	// Requests for hosts without a matching pattern are handled by the routes of the webapp.
+	//
+	// Every host pattern has its own router, created with the router factory (see WithRouterFactory). Like a Group,
+	// the host routes run the middleware added with Use before Host is called, middleware added with Use afterwards
+	// only runs for the routes of the webapp.
+	Host(pattern string, middleware ...MiddlewareFunc) RouteGroup
+
+	// Mount serves all the requests for the prefix and the paths below the prefix with the webapp, the prefix is
+	// stripped from the request path (`/billing/invoices` is handled as `/invoices`). The mounted webapp is isolated by
+	// default, use MountShared to run it with the error handler, binder and other services of this webapp.
+	// Middleware registered with Use before mounting runs before the mounted webapp.
+	Mount(prefix string, app WebApp, options ...MountOption) []RouteInfo
+
+	// MountHandler serves all the requests for the prefix and the paths below the prefix with the http.Handler, the
+	// prefix is stripped from the request path like http.StripPrefix.
+	MountHandler(prefix string, handler http.Handler) []RouteInfo
+
 	RouteGroup
 }
 
+// DefaultMultipartMemory is the maximum number of bytes of a multipart form that are stored in memory
+const DefaultMultipartMemory = 32 << 20
+
 func New(options ...Option) WebApp {
 	app := &webapp{}
 	app.contextPool.New = func() any {
 		return app.newContext()
 	}
 	app.router = NewDefaultRouter()
+	app.routerFactory = NewDefaultRouter
 	app.routes = make(routes)
 	app.binder = DefaultBinder
-	app.jsonEncoder = DefaultJSONEncoder
+	app.encodings = defaultEncodings()
 	app.errorHandler = DefaultErrorHandler
+	app.recover = true
+	app.multipartMemory = DefaultMultipartMemory
+	app.ipExtractor = ExtractIPDirect()
 
 	// Apply options that overwrite the default behaviour of the webapp
 	for _, option := range options {
@@ -35,29 +65,39 @@ func New(options ...Option) WebApp {
 
 	// Init after options
 	app.routeInfoGroup = &routeInfoGroup{
-		routes:     app.routes,
+		app:        app,
 		RouteGroup: app.router.Group(""),
-		//webapp:     app,
 	}
-	app.handler = app.router.Handle
+	app.handler = app.route
 
 	return app
 }
 
 type webapp struct {
 	preMiddleware []MiddlewareFunc
+	middleware    []MiddlewareFunc
 	errorHandler  ErrorHandler
-
-	contextPool sync.Pool
-	maxParams   int
-
-	router      Router
-	routes      routes
-	handler     HandlerFunc
-	binder      Binder
-	jsonEncoder JSONEncoding
-	renderer    Renderer
-	validator   Validator
+	recover       bool
+
+	contextPool     sync.Pool
+	maxParams       int
+	multipartMemory int64
+	ipExtractor     IPExtractor
+
+	serverLock sync.Mutex
+	servers    []*http.Server
+	listeners  []net.Listener
+	onShutdown []func()
+
+	router        Router
+	routerFactory func() Router
+	hosts         hostRouter
+	routes        routes
+	handler       HandlerFunc
+	binder        Binder
+	renderer      Renderer
+	validator     Validator
+	encodings     encodings
 
 	*routeInfoGroup
 }
@@ -71,44 +111,173 @@ func (a *webapp) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	c := a.contextPool.Get().(*context)
 	c.reset(r, w)
 
+	// Release context back to the pool, also when the handler panics
+	defer a.contextPool.Put(c)
+
+	if a.recover {
+		defer a.recoverPanic(c)
+	}
+
 	// Execute chain
 	if err := a.handler(c); err != nil {
-		a.errorHandler(c, err)
+		a.handleError(c, err)
+	}
+}
+
+// recoverPanic recovers a panic of the handler chain and passes it as a PanicError to the error handler
+func (a *webapp) recoverPanic(c *context) {
+	if r := recover(); r != nil {
+		// the abort handler panic is used to abort a response, this panic is handled by the http server
+		if r == http.ErrAbortHandler {
+			panic(r)
+		}
+		a.handleError(c, NewPanicError(r))
+	}
+}
+
+// handleError passes the error to the error handler, when the error handler could not handle the error and nothing
+// is sent yet, an internal server error is sent
+func (a *webapp) handleError(c *context, err error) {
+	if err = a.errorHandler(c, err); err != nil && !c.response.HeaderSend() {
+		c.response.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// route passes the request to the router of the matching host, the requests for unknown hosts are handled by the
+// main router
+func (a *webapp) route(c Context) error {
+	if a.hosts.empty() {
+		return a.router.Handle(c)
 	}
 
-	// Release context back to the pool
-	a.contextPool.Put(c)
+	pc := c.(ParamsContext)
+	if host := a.hosts.find(c.Request().Host, pc.HostParamValuesPtr()); host != nil {
+		pc.SetHostParamNames(host.params...)
+		return host.router.Handle(c)
+	}
+	return a.router.Handle(c)
+}
+
+func (a *webapp) Host(pattern string, middleware ...MiddlewareFunc) RouteGroup {
+	m := make([]MiddlewareFunc, 0, len(a.middleware)+len(middleware))
+	m = append(m, a.middleware...)
+	m = append(m, middleware...)
+
+	router := a.hosts.add(pattern, a.routerFactory)
+	return &routeInfoGroup{
+		app:        a,
+		RouteGroup: router.Group("", m...),
+	}
+}
+
+// Use adds the middleware to the routes of the webapp and the host routes registered afterwards
+func (a *webapp) Use(middleware ...MiddlewareFunc) {
+	a.middleware = append(a.middleware, middleware...)
+	a.routeInfoGroup.Use(middleware...)
 }
 
 func (a *webapp) Start(address string) error {
 	server := new(http.Server)
-	server.Handler = a
 	server.Addr = address
+	return a.StartServer(server)
+}
 
-	l, err := net.Listen("tcp", address)
+func (a *webapp) StartServer(s *http.Server) (err error) {
+	address := s.Addr
+	if address == "" {
+		address = ":http"
+	}
+
+	l, err := listen(address)
 	if err != nil {
 		return err
 	}
-
-	//TODO add close and listener to webapp for gracfull closing
-	//TODO examine the tcp handling
-
-	return server.Serve(l)
+	return a.serve(s, l)
 }
 
-func (a *webapp) StartServer(s *http.Server) (err error) {
-	//TODO implement me
-	panic("implement me")
+func (a *webapp) StartListener(l net.Listener) error {
+	return a.serve(new(http.Server), l)
 }
 
 func (a *webapp) Close() error {
-	//TODO implement me
-	panic("implement me")
+	a.serverLock.Lock()
+	servers := slices.Clone(a.servers)
+	a.serverLock.Unlock()
+
+	errs := make([]error, len(servers))
+	for i, s := range servers {
+		errs[i] = s.Close()
+	}
+	return errors.Join(errs...)
 }
 
 func (a *webapp) Shutdown(ctx stdContext.Context) error {
-	//TODO implement me
-	panic("implement me")
+	a.serverLock.Lock()
+	servers := slices.Clone(a.servers)
+	hooks := slices.Clone(a.onShutdown)
+	a.serverLock.Unlock()
+
+	// All servers drain their in-flight requests in parallel, so they share the deadline of the context
+	errs := make([]error, len(servers))
+	wg := sync.WaitGroup{}
+	for i, s := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = s.Shutdown(ctx)
+		}()
+	}
+	wg.Wait()
+
+	for _, hook := range hooks {
+		hook()
+	}
+	return errors.Join(errs...)
+}
+
+func (a *webapp) RegisterOnShutdown(f func()) {
+	a.serverLock.Lock()
+	defer a.serverLock.Unlock()
+
+	a.onShutdown = append(a.onShutdown, f)
+}
+
+func (a *webapp) ListenerAddr() net.Addr {
+	a.serverLock.Lock()
+	defer a.serverLock.Unlock()
+
+	if len(a.listeners) == 0 {
+		return nil
+	}
+	return a.listeners[0].Addr()
+}
+
+// serve keeps track of the server and listener while the server is accepting connections, so they can be closed or
+// shutdown by the webapp
+func (a *webapp) serve(s *http.Server, l net.Listener) error {
+	if s.Handler == nil {
+		s.Handler = a
+	}
+
+	a.serverLock.Lock()
+	a.servers = append(a.servers, s)
+	a.listeners = append(a.listeners, l)
+	a.serverLock.Unlock()
+
+	defer func() {
+		a.serverLock.Lock()
+		defer a.serverLock.Unlock()
+
+		if i := slices.Index(a.servers, s); i >= 0 {
+			a.servers = slices.Delete(a.servers, i, i+1)
+			a.listeners = slices.Delete(a.listeners, i, i+1)
+		}
+	}()
+
+	if s.TLSConfig != nil {
+		return s.ServeTLS(l, "", "")
+	}
+	return s.Serve(l)
 }
 
 func (a *webapp) Binder() Binder {
@@ -139,22 +308,20 @@ func (a *webapp) Logger() Logger {
 }
 
 func (a *webapp) JsonEncoder() JSONEncoding {
-	return a.jsonEncoder
+	return a.encodings.encoder(MIMEApplicationJSON)
 }
 
 func (a *webapp) XmlEncoder() XMLEncoding {
-	//TODO implement me
-	panic("implement me")
+	return a.encodings.encoder(MIMEApplicationXML)
 }
 
-func (a *webapp) Pre(middleware ...MiddlewareFunc) {
-	a.preMiddleware = append(a.preMiddleware, middleware...)
-	a.handler = applyMiddleware(a.router.Handle, a.preMiddleware...)
+func (a *webapp) Encodings() []Encoding {
+	return a.encodings
 }
 
-func (a *webapp) RouteNotFound(path string, h HandlerFunc, m ...MiddlewareFunc) RouteInfo {
-	//TODO implement me
-	panic("implement me")
+func (a *webapp) Pre(middleware ...MiddlewareFunc) {
+	a.preMiddleware = append(a.preMiddleware, middleware...)
+	a.handler = applyMiddleware(a.route, a.preMiddleware...)
 }
 
 // newContext is the factory method for creating a new one
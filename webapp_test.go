@@ -87,3 +87,44 @@ func Test_webapp_pre_runs_on_not_found_route(T *testing.T) {
 	assert.True(T, preDidRun)
 	assert.Equal(T, 404, rw.Code)
 }
+
+func Test_webapp_named_routes(T *testing.T) {
+	handler := func(c Context) error { return nil }
+
+	app := New()
+	show := app.GET("/users/{id}", handler).Named("user.show").Tag("users").Describe("Show a user")
+	app.GET("/people/{id}", handler).Deprecate().Named("people.show")
+
+	assert.Equal(T, "user.show", show.Name())
+	assert.Equal(T, RouteMeta{Tags: []string{"users"}, Summary: "Show a user"}, show.Meta())
+	assert.Equal(T, show, app.Routes().Get("user.show"))
+	assert.True(T, app.Routes().Get("people.show").Meta().Deprecated)
+	assert.Nil(T, app.Routes().Get(handlerName(handler)))
+	assert.Len(T, app.Routes().All(), 2)
+
+	uri, err := app.Routes().Reverse("user.show", 12)
+	require.NoError(T, err)
+	assert.Equal(T, "/users/12", uri)
+}
+
+func Test_webapp_derived_route_names_do_not_collide(T *testing.T) {
+	handler := func(c Context) error { return nil }
+
+	app := New()
+	first := app.GET("/users", handler)
+	second := app.GET("/people", handler)
+
+	assert.Equal(T, handlerName(handler), first.Name())
+	assert.Equal(T, MethodQualifiedName(handlerName(handler), http.MethodGet)+" /people", second.Name())
+	assert.Equal(T, second, app.Routes().Get(second.Name()))
+	assert.Len(T, app.Routes().All(), 2)
+}
+
+func Test_webapp_duplicate_route_name_panics(T *testing.T) {
+	app := New()
+	app.GET("/users/{id}", func(c Context) error { return nil }).Named("user.show")
+
+	assert.Panics(T, func() {
+		app.GET("/people/{id}", func(c Context) error { return nil }).Named("user.show")
+	})
+}
@@ -0,0 +1,50 @@
+package webapp
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// DefaultXMLEncoding implements the xml Encoding using encoding/xml, registered by default.
+var DefaultXMLEncoding IndentEncoding
+
+// DefaultXMLEncoder implements XML encoding using encoding/xml.
+var DefaultXMLEncoder XMLEncoding
+
+func init() {
+	DefaultXMLEncoding = xmlEncoding{}
+	DefaultXMLEncoder = encodingEncoder{encoding: DefaultXMLEncoding}
+}
+
+type xmlEncoding struct{}
+
+func (xmlEncoding) MediaType() string {
+	return MIMEApplicationXML
+}
+
+// Encode converts an interface into a xml and writes it to the writer.
+func (e xmlEncoding) Encode(w io.Writer, i interface{}) error {
+	return e.EncodeIndent(w, i, "")
+}
+
+// EncodeIndent converts an interface into a pretty printed xml and writes it to the writer.
+func (xmlEncoding) EncodeIndent(w io.Writer, i interface{}, indent string) error {
+	enc := xml.NewEncoder(w)
+	if indent != "" {
+		enc.Indent("", indent)
+	}
+	return enc.Encode(i)
+}
+
+// Decode reads a XML from the reader and converts it into an interface.
+func (xmlEncoding) Decode(r io.Reader, i interface{}) error {
+	err := xml.NewDecoder(r).Decode(i)
+	if ute, ok := err.(*xml.UnsupportedTypeError); ok {
+		return NewHTTPErrorWithInternal(http.StatusBadRequest, err, fmt.Sprintf("Unsupported type error: type=%v, error=%v", ute.Type, ute.Error()))
+	} else if se, ok := err.(*xml.SyntaxError); ok {
+		return NewHTTPErrorWithInternal(http.StatusBadRequest, err, fmt.Sprintf("Syntax error: line=%v, error=%v", se.Line, se.Error()))
+	}
+	return err
+}